@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/services"
+
+	"github.com/joho/godotenv"
+)
+
+// 初始化第一个管理员账号
+// 用法: go run ./cmd/seed -e development -name admin -password 123456
+func main() {
+	environment := flag.String("e", "development", "")
+	name := flag.String("name", "", "管理员用户名")
+	mobile := flag.String("mobile", "", "管理员手机号（可选）")
+	password := flag.String("password", "", "管理员密码，至少 6 位")
+	flag.Parse()
+
+	if len(*name) < 3 || len(*password) < 6 {
+		fmt.Println("Usage: seed -e {mode} -name {name} -password {password} [-mobile {mobile}]")
+		os.Exit(1)
+	}
+
+	config.Init(*environment)
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("加载 .env 文件失败: ", err)
+	}
+	db.InitDB()
+
+	user, err := services.SeedAdmin(*name, *mobile, *password)
+	if err != nil {
+		log.Fatal("创建管理员失败: ", err)
+	}
+	fmt.Printf("管理员 %s 创建成功 (id=%s)\n", user.Name, user.ID)
+}
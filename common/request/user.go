@@ -1,16 +1,39 @@
 package request
 
 type Register struct {
-	Name     string `form:"name" json:"name" binding:"required"`
-	Mobile   string `form:"mobile" json:"mobile" binding:"required"`
-	Password string `form:"password" json:"password" binding:"required"`
+	Name     string `form:"name" json:"name" binding:"required,min=3,max=100"`
+	Mobile   string `form:"mobile" json:"mobile" binding:"required,numeric,len=11"`
+	Password string `form:"password" json:"password" binding:"required,min=6,max=72"`
+	Email    string `form:"email" json:"email" binding:"omitempty,email"`
 }
 
 // 自定义错误信息
 func (register Register) GetMessages() ValidatorMessages {
 	return ValidatorMessages{
 		"Name.required":     "用户名称不能为空",
+		"Name.min":          "用户名称不能少于 3 个字符",
+		"Name.max":          "用户名称不能超过 100 个字符",
 		"Mobile.required":   "手机号码不能为空",
+		"Mobile.numeric":    "手机号码格式不正确",
+		"Mobile.len":        "手机号码格式不正确",
 		"Password.required": "用户密码不能为空",
+		"Password.min":      "用户密码不能少于 6 位",
+		"Password.max":      "用户密码不能超过 72 位",
+		"Email.email":       "邮箱格式不正确",
+	}
+}
+
+type ChangePassword struct {
+	OldPassword string `form:"oldPassword" json:"oldPassword" binding:"required"`
+	NewPassword string `form:"newPassword" json:"newPassword" binding:"required,min=6,max=72"`
+}
+
+// 自定义错误信息
+func (changePassword ChangePassword) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"OldPassword.required": "原密码不能为空",
+		"NewPassword.required": "新密码不能为空",
+		"NewPassword.min":      "新密码不能少于 6 位",
+		"NewPassword.max":      "新密码不能超过 72 位",
 	}
 }
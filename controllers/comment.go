@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services"
+	"blog-server/services/spam"
+	"blog-server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseIDParam 解析路径中的 :id
+func parseIDParam(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, utils.NewAPIError(http.StatusBadRequest, "ID 不合法")
+	}
+	return uint(id), nil
+}
+
+// buildCommentTree 把楼层内的回复挂到各自的父评论下
+// 未通过的评论只在其下还有可见回复时作为占位展示，不输出作者和内容，否则整条分支不展示
+func buildCommentTree(roots, replies []models.Comment) []forms.CommentItem {
+	children := make(map[uint][]models.Comment)
+	for _, r := range replies {
+		children[*r.ParentID] = append(children[*r.ParentID], r)
+	}
+
+	var build func(c models.Comment) (forms.CommentItem, bool)
+	build = func(c models.Comment) (forms.CommentItem, bool) {
+		item := forms.CommentItem{
+			ID:            c.ID,
+			ParentID:      c.ParentID,
+			AuthorName:    c.AuthorName,
+			AuthorWebsite: c.AuthorWebsite,
+			Content:       c.Content,
+			CreatedAt:     c.CreatedAt,
+			Replies:       []forms.CommentItem{},
+		}
+		for _, child := range children[c.ID] {
+			if reply, ok := build(child); ok {
+				item.Replies = append(item.Replies, reply)
+			}
+		}
+		if c.Status != models.CommentStatusApproved {
+			if len(item.Replies) == 0 {
+				return item, false
+			}
+			item.AuthorName, item.AuthorWebsite, item.Content = "", "", ""
+			item.Deleted = true
+		}
+		return item, true
+	}
+
+	list := make([]forms.CommentItem, 0, len(roots))
+	for _, r := range roots {
+		if item, ok := build(r); ok {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func toModerationCommentItem(c models.Comment) forms.ModerationCommentItem {
+	return forms.ModerationCommentItem{
+		ID:            c.ID,
+		PostID:        c.PostID,
+		ParentID:      c.ParentID,
+		UserID:        c.UserID,
+		AuthorName:    c.AuthorName,
+		AuthorEmail:   c.AuthorEmail,
+		AuthorWebsite: c.AuthorWebsite,
+		Content:       c.Content,
+		Status:        c.Status,
+		SpamReason:    c.SpamReason,
+		IP:            c.IP,
+		UserAgent:     c.UserAgent,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+}
+
+// commentError 把评论相关的业务错误转换成对应的状态码
+func commentError(err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrCommentNotFound),
+		errors.Is(err, services.ErrCommentPostNotFound):
+		return utils.NewAPIError(http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCommentParentInvalid),
+		errors.Is(err, services.ErrCommentAuthorMissing),
+		errors.Is(err, services.ErrCommentRejected):
+		return utils.NewAPIError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCommentRateLimited):
+		return utils.NewAPIError(http.StatusTooManyRequests, err.Error())
+	}
+	return utils.NewAPIError(http.StatusInternalServerError, message, err)
+}
+
+// GetPostComments 获取文章下已通过的评论，按顶层评论分页
+func GetPostComments(c *gin.Context, q forms.FetchCommentsQuery) (forms.CommentsPage, error) {
+	postID, err := parseIDParam(c)
+	if err != nil {
+		return forms.CommentsPage{}, err
+	}
+
+	roots, replies, total, err := services.ListApprovedComments(postID, q.Page, q.PageSize)
+	if err != nil {
+		return forms.CommentsPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取评论失败", err)
+	}
+
+	return forms.CommentsPage{
+		Total: total,
+		List:  buildCommentTree(roots, replies),
+	}, nil
+}
+
+// GetCommentFormToken 打开评论框时获取表单令牌，提交评论时带上
+func GetCommentFormToken(c *gin.Context) (forms.CommentFormToken, error) {
+	postID, err := parseIDParam(c)
+	if err != nil {
+		return forms.CommentFormToken{}, err
+	}
+	return forms.CommentFormToken{Token: spam.IssueFormToken(postID)}, nil
+}
+
+// CreateComment 发表评论，读者的评论需要审核后才会展示
+func CreateComment(c *gin.Context, body forms.CreateCommentBody) (forms.CreateCommentResponse, error) {
+	postID, err := parseIDParam(c)
+	if err != nil {
+		return forms.CreateCommentResponse{}, err
+	}
+
+	comment, err := services.CreateComment(c.Request.Context(), currentViewer(c), currentUsername(c), services.CommentInput{
+		PostID:        postID,
+		ParentID:      body.ParentID,
+		AuthorName:    body.AuthorName,
+		AuthorEmail:   body.AuthorEmail,
+		AuthorWebsite: body.AuthorWebsite,
+		Content:       body.Content,
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Referrer:      c.Request.Referer(),
+		Honeypot:      body.Homepage,
+		FormToken:     body.FormToken,
+	})
+	if err != nil {
+		return forms.CreateCommentResponse{}, commentError(err, "发表评论失败")
+	}
+
+	return forms.CreateCommentResponse{ID: comment.ID, Status: comment.Status}, nil
+}
+
+// ListCommentsForModeration 评论审核列表
+func ListCommentsForModeration(c *gin.Context, q forms.ModerationCommentsQuery) (forms.ModerationCommentsPage, error) {
+	comments, total, err := services.ListCommentsForModeration(q.Status, q.PostID, q.Page, q.PageSize)
+	if err != nil {
+		return forms.ModerationCommentsPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取评论失败", err)
+	}
+
+	list := make([]forms.ModerationCommentItem, len(comments))
+	for i, comment := range comments {
+		list[i] = toModerationCommentItem(comment)
+	}
+	return forms.ModerationCommentsPage{Total: total, List: list}, nil
+}
+
+func setCommentStatus(c *gin.Context, status string) (forms.ModerationCommentItem, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return forms.ModerationCommentItem{}, err
+	}
+
+	comment, err := services.SetCommentStatus(id, status)
+	if err != nil {
+		return forms.ModerationCommentItem{}, commentError(err, "修改评论状态失败")
+	}
+	return toModerationCommentItem(*comment), nil
+}
+
+// ApproveComment 审核通过
+func ApproveComment(c *gin.Context) (forms.ModerationCommentItem, error) {
+	return setCommentStatus(c, models.CommentStatusApproved)
+}
+
+// RejectComment 拒绝评论，标记为垃圾评论
+func RejectComment(c *gin.Context) (forms.ModerationCommentItem, error) {
+	return setCommentStatus(c, models.CommentStatusSpam)
+}
+
+// UpdateComment 编辑评论内容
+func UpdateComment(c *gin.Context, body forms.UpdateCommentBody) (forms.ModerationCommentItem, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return forms.ModerationCommentItem{}, err
+	}
+
+	comment, err := services.UpdateComment(id, body.AuthorName, body.AuthorWebsite, body.Content)
+	if err != nil {
+		return forms.ModerationCommentItem{}, commentError(err, "修改评论失败")
+	}
+	return toModerationCommentItem(*comment), nil
+}
+
+// DeleteComments 批量删除评论，返回实际删除的条数
+func DeleteComments(c *gin.Context, q forms.DeleteCommentsQuery) (int64, error) {
+	deleted, err := services.DeleteComments(q.IDs)
+	if err != nil {
+		return 0, utils.NewAPIError(http.StatusInternalServerError, "删除评论失败", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"blog-server/config"
+	"blog-server/services"
+	"blog-server/utils"
+	"blog-server/utils/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type feedFormat struct {
+	kind        string
+	contentType string
+	render      func(*services.Feed) ([]byte, error)
+}
+
+var (
+	rssFormat  = feedFormat{"rss", "application/rss+xml; charset=utf-8", (*services.Feed).RSS}
+	atomFormat = feedFormat{"atom", "application/atom+xml; charset=utf-8", (*services.Feed).Atom}
+	jsonFormat = feedFormat{"json", "application/feed+json; charset=utf-8", (*services.Feed).JSONFeed}
+)
+
+// RSSFeed RSS 2.0 订阅
+func RSSFeed(c *gin.Context) { serveFeed(c, rssFormat, "") }
+
+// AtomFeed Atom 订阅
+func AtomFeed(c *gin.Context) { serveFeed(c, atomFormat, "") }
+
+// JSONFeed JSON Feed 订阅
+func JSONFeed(c *gin.Context) { serveFeed(c, jsonFormat, "") }
+
+// TagRSSFeed 单个标签的 RSS 订阅
+func TagRSSFeed(c *gin.Context) { serveFeed(c, rssFormat, c.Param("name")) }
+
+func serveFeed(c *gin.Context, format feedFormat, tagName string) {
+	limit := config.GetConfig().GetInt("feed.limit")
+	if limit <= 0 {
+		limit = 20
+	}
+
+	posts, err := services.LatestFeedPosts(tagName, limit)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, http.StatusNotFound, "标签不存在")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取文章失败")
+		return
+	}
+
+	etag := services.FeedETag(format.kind, tagName, posts)
+	if utils.SetCacheValidators(c, etag, services.FeedLastModified(posts)) {
+		return
+	}
+
+	feed, err := services.BuildFeed(posts, tagName, services.SiteURL()+c.Request.URL.Path)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "生成订阅失败")
+		return
+	}
+	body, err := format.render(feed)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "生成订阅失败")
+		return
+	}
+
+	c.Data(http.StatusOK, format.contentType, body)
+}
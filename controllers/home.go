@@ -15,11 +15,17 @@ import (
 func GetNews(c *gin.Context) {
 	var posts []models.Post
 
-	if err := db.DB.Order("created_at DESC").Limit(5).Find(&posts).Error; err != nil {
+	if err := db.DB.Scopes(services.PublishedPosts).Order("created_at DESC").Limit(5).Find(&posts).Error; err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取失败")
 		return
 	}
 
+	tagNames, err := services.GetTagNamesByPostIDs(postIDs(posts))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取标签失败")
+		return
+	}
+
 	var newsItems []forms.NewsItem
 	for _, post := range posts {
 		description := post.Content
@@ -28,16 +34,12 @@ func GetNews(c *gin.Context) {
 			description = string(runes[:100]) + "..."
 		}
 
-		tagNames, err := services.GetTagNamesByIDs(post.TagIDs)
-		if err != nil {
-			response.Error(c, http.StatusInternalServerError, "获取标签失败")
-			return
-		}
 		newsItem := forms.NewsItem{
 			ID:          post.ID,
 			Title:       post.Title,
+			Slug:        post.Slug,
 			Description: description,
-			Tags:        tagNames,
+			Tags:        tagNames[post.ID],
 			AdjustTime:  post.AdjustTime.Format("2006-01-02 15:04"),
 			ImgUrl:      post.ImgUrl,
 		}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"net/http"
+
+	"blog-server/services/token"
+	"blog-server/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS 返回用于校验 token 的公钥集合
+func JWKS(c *gin.Context) {
+	keys, err := token.PublicJWKS()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "加载密钥失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -1,18 +1,21 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"strings"
+	"net/url"
+	"time"
 
 	"blog-server/db"
 	"blog-server/forms"
-	"blog-server/forms/post"
 	"blog-server/models"
 	"blog-server/services"
+	"blog-server/services/searchquery"
 	"blog-server/utils"
 	"blog-server/utils/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreatePost 创建文章
@@ -26,65 +29,179 @@ import (
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /blog [post]
 func CreatePost(c *gin.Context, body forms.CreatePostBody) (forms.PostResponse, error) {
+	status, publishAt, err := services.ResolvePostStatus(body.Status, body.PublishAt)
+	if err != nil {
+		return forms.PostResponse{}, utils.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+
 	tagIDs, err := services.ResolveTagIDs(body.Tags)
 	if err != nil {
 		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "标签处理失败", err)
 	}
 
 	post := models.Post{
-		Title:   body.Title,
-		Content: body.Content,
-		ImgUrl:  body.ImgUrl,
-		TagIDs:  tagIDs,
+		Title:     body.Title,
+		Content:   body.Content,
+		ImgUrl:    body.ImgUrl,
+		AuthorID:  c.GetString("userID"),
+		Status:    status,
+		PublishAt: publishAt,
 	}
+	services.ApplyPostStats(&post)
 
-	if err := db.DB.Create(&post).Error; err != nil {
-		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "文章创建失败", err)
-	}
-	// --- 自动生成 tokens ---
-	if err := services.UpdatePostTokens(&post); err != nil {
-		// 如果分词失败，可以选择回滚或记录错误
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		err := services.SavePostWithSlug(tx, post.Title, body.Slug, 0, func(slug string) error {
+			post.Slug = slug
+			return tx.Create(&post).Error
+		})
+		if err != nil {
+			return err
+		}
+		if err := services.SetPostTags(tx, post.ID, tagIDs); err != nil {
+			return err
+		}
+		if _, err := services.CreatePostRevision(tx, &post, currentUsername(c)); err != nil {
+			return err
+		}
+		// 分词失败时整个事务回滚，不会留下没有索引的文章
+		return services.UpdatePostTokens(tx, &post)
+	})
+	var indexErr *services.IndexError
+	if errors.As(err, &indexErr) {
 		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "文章分词失败", err)
 	}
+	if err != nil {
+		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "文章创建失败", err)
+	}
 	// 转换成响应对象返回前端
 	resp := forms.PostResponse{
-		ID:         post.ID,
-		Title:      post.Title,
-		ImgUrl:     post.ImgUrl,
-		AdjustTime: post.AdjustTime.Format("2006-01-02 15:04:05"),
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		ImgUrl:      post.ImgUrl,
+		AdjustTime:  post.AdjustTime.Format("2006-01-02 15:04:05"),
+		Status:      post.Status,
+		PublishAt:   formatPublishAt(post.PublishAt),
+		TOC:         post.TOC,
+		WordCount:   post.WordCount,
+		ReadingTime: post.ReadingTime,
 	}
 
 	return resp, nil
 }
 
+// currentViewer 返回当前请求的用户身份，未登录时为匿名用户
+func currentViewer(c *gin.Context) services.Viewer {
+	return services.Viewer{
+		UserID: c.GetString("userID"),
+		Role:   c.GetString("role"),
+	}
+}
+
+// currentUsername 返回当前登录用户名，未登录时为空
+func currentUsername(c *gin.Context) string {
+	username, _ := c.Get("username")
+	name, _ := username.(string)
+	return name
+}
+
+func formatPublishAt(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
 // GetPost 获取单篇文章
 func GetPost(c *gin.Context, q forms.FetchPostQuery) (forms.PostResponse, error) {
 	id := q.Seq
 
 	var post models.Post
-	if err := db.DB.First(&post, id).Error; err != nil {
+	if err := db.DB.Scopes(services.PostVisibility(currentViewer(c), "")).First(&post, id).Error; err != nil {
 		return forms.PostResponse{}, utils.NewAPIError(http.StatusBadRequest, "文章获取失败", err)
 	}
 
-	// 压缩 Markdown 字段
-	compressed, err := utils.CompressAndEncode([]byte(post.Content))
+	return buildPostResponse(post, q.Format)
+}
+
+// GetPostBySlug 根据 slug 获取单篇文章，旧 slug 301 跳转到当前地址
+func GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var post models.Post
+	err := db.DB.Scopes(services.PostVisibility(currentViewer(c), "")).Where("slug = ?", slug).First(&post).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if current, err := services.ResolveSlugRedirect(currentViewer(c), slug); err == nil {
+			c.Redirect(http.StatusMovedPermanently, "/api/blog/by-slug/"+url.PathEscape(current))
+			return
+		}
+		response.Error(c, http.StatusNotFound, "文章不存在")
+		return
+	}
 	if err != nil {
-		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "压缩文章失败", err)
+		response.Error(c, http.StatusInternalServerError, "文章获取失败")
+		return
 	}
-	tagNames, err := services.GetTagNamesByIDs(post.TagIDs)
+
+	format := c.Query("format")
+	if format != "" && format != forms.ContentFormatMarkdown && format != forms.ContentFormatHTML {
+		response.Error(c, http.StatusBadRequest, "format 只能是 markdown 或 html")
+		return
+	}
+
+	resp, err := buildPostResponse(post, format)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.(*utils.APIError).Message)
+		return
+	}
+	response.Ok(c, resp)
+}
+
+// postIDs 返回文章列表的 ID，用于批量查询标签
+func postIDs(posts []models.Post) []uint {
+	ids := make([]uint, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// buildPostResponse 构造单篇文章的响应，默认返回压缩后的 Markdown，format=html 时返回渲染后的 HTML
+func buildPostResponse(post models.Post, format string) (forms.PostResponse, error) {
+	var content string
+	var err error
+	if format == forms.ContentFormatHTML {
+		content, err = services.RenderPostHTML(post)
+		if err != nil {
+			return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "渲染文章失败", err)
+		}
+	} else {
+		format = forms.ContentFormatMarkdown
+		// 压缩 Markdown 字段
+		content, err = utils.CompressAndEncode([]byte(post.Content))
+		if err != nil {
+			return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "压缩文章失败", err)
+		}
+	}
+	tagNames, err := services.GetPostTagNames(post.ID)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "获取标签失败")
 		return forms.PostResponse{}, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
 	}
-	// 返回时替换原字段
-	post.Content = compressed
+
 	resp := forms.PostResponse{
-		ID:         post.ID,
-		Title:      post.Title,
-		ImgUrl:     post.ImgUrl,
-		Tags:       tagNames,
-		Content:    compressed,
-		AdjustTime: post.AdjustTime.Format("2006-01-02 15:04:05"),
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		ImgUrl:      post.ImgUrl,
+		Tags:        tagNames,
+		Content:     content,
+		Format:      format,
+		AdjustTime:  post.AdjustTime.Format("2006-01-02 15:04:05"),
+		Status:      post.Status,
+		PublishAt:   formatPublishAt(post.PublishAt),
+		TOC:         post.TOC,
+		WordCount:   post.WordCount,
+		ReadingTime: post.ReadingTime,
 	}
 
 	return resp, nil
@@ -94,34 +211,40 @@ func GetPost(c *gin.Context, q forms.FetchPostQuery) (forms.PostResponse, error)
 func GetPosts(c *gin.Context, q forms.FetchPostsQuery) (forms.PostsPage, error) {
 	var posts []models.Post
 	var total int64
+	visibility := services.PostVisibility(currentViewer(c), q.Status)
+	tagFilter := services.PostsWithTags(q.Tags, q.TagMode)
 
 	// 计算总数
-	if err := db.DB.Model(&models.Post{}).Count(&total).Error; err != nil {
+	if err := db.DB.Model(&models.Post{}).Scopes(visibility, tagFilter).Count(&total).Error; err != nil {
 		return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "查询总数失败", err)
 	}
 
 	offset := (q.Page - 1) * q.PageSize
 
-	// 查询分页数据
-	if err := db.DB.Order("created_at DESC").Limit(q.PageSize).Offset(offset).Find(&posts).Error; err != nil {
+	// 查询分页数据，列表不需要正文
+	if err := db.DB.Scopes(visibility, tagFilter).Omit("content", "tokens").Order("created_at DESC").Limit(q.PageSize).Offset(offset).Find(&posts).Error; err != nil {
 		return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "查询文章失败", err)
 	}
 
+	tagNames, err := services.GetTagNamesByPostIDs(postIDs(posts))
+	if err != nil {
+		return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
+	}
+
 	// 转换为 DTO
 	list := make([]forms.PostItem, len(posts))
 	for i, p := range posts {
-		tagNames, err := services.GetTagNamesByIDs(p.TagIDs)
-		if err != nil {
-			response.Error(c, http.StatusInternalServerError, "获取标签失败")
-			return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
-		}
-
 		list[i] = forms.PostItem{
-			ID:         p.ID,
-			Title:      p.Title,
-			ImgUrl:     p.ImgUrl,
-			Tags:       tagNames,
-			AdjustTime: p.AdjustTime,
+			ID:          p.ID,
+			Title:       p.Title,
+			Slug:        p.Slug,
+			ImgUrl:      p.ImgUrl,
+			Tags:        tagNames[p.ID],
+			AdjustTime:  p.AdjustTime,
+			Status:      p.Status,
+			TOC:         p.TOC,
+			WordCount:   p.WordCount,
+			ReadingTime: p.ReadingTime,
 		}
 	}
 
@@ -131,26 +254,30 @@ func GetPosts(c *gin.Context, q forms.FetchPostsQuery) (forms.PostsPage, error)
 	}, nil
 }
 
-// GetTags 获取所有标签
+// GetTags 获取所有标签及其下已发布文章数
 func GetTags(c *gin.Context) {
-	var tags []models.Tag
-	if err := db.DB.Find(&tags).Error; err != nil {
+	counts, err := services.ListTagCounts(true)
+	if err != nil {
 		response.Error(c, http.StatusNotFound, "获取失败")
 		return
 	}
-	var ts []string
-	for _, t := range tags {
-		ts = append(ts, t.Name)
+	ts := make([]forms.TagItem, len(counts))
+	for i, t := range counts {
+		ts[i] = forms.TagItem{
+			Name:        t.Name,
+			Slug:        t.Slug,
+			Description: t.Description,
+			Color:       t.Color,
+			Count:       t.Count,
+		}
 	}
 	response.Ok(c, ts, "获取成功")
 }
 
 // UpdatePost 更新文章
 func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
-	var post models.Post
-	if err := db.DB.First(&post, id).Error; err != nil {
-		response.Error(c, http.StatusNotFound, "文章不存在")
+	post, ok := loadManagedPost(c)
+	if !ok {
 		return
 	}
 
@@ -160,6 +287,11 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	if err := services.ApplyPostStatusUpdate(&post, postBody.Status, postBody.PublishAt); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// 调用通用方法获取 tagIDs
 	tagIDs, err := services.ResolveTagIDs(postBody.Tags)
 	if err != nil {
@@ -170,9 +302,40 @@ func UpdatePost(c *gin.Context) {
 	post.Title = postBody.Title
 	post.Content = postBody.Content
 	post.ImgUrl = postBody.ImgUrl
-	post.TagIDs = tagIDs
-
-	if err := db.DB.Save(&post).Error; err != nil {
+	services.ApplyPostStats(&post)
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// 未指定 slug 时保留原地址，避免改标题导致链接失效
+		if postBody.Slug != "" || post.Slug == "" {
+			oldSlug := post.Slug
+			err := services.SavePostWithSlug(tx, post.Title, postBody.Slug, post.ID, func(slug string) error {
+				// 重试时从原 slug 重新开始，避免把冲突的 slug 记为旧地址
+				post.Slug = oldSlug
+				if err := services.ApplyPostSlug(tx, &post, slug); err != nil {
+					return err
+				}
+				return tx.Save(&post).Error
+			})
+			if err != nil {
+				return err
+			}
+		} else if err := tx.Save(&post).Error; err != nil {
+			return err
+		}
+		if err := services.SetPostTags(tx, post.ID, tagIDs); err != nil {
+			return err
+		}
+		if _, err := services.CreatePostRevision(tx, &post, currentUsername(c)); err != nil {
+			return err
+		}
+		return services.UpdatePostTokens(tx, &post)
+	})
+	var indexErr *services.IndexError
+	if errors.As(err, &indexErr) {
+		response.Error(c, http.StatusInternalServerError, "文章分词失败")
+		return
+	}
+	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "文章更新失败")
 		return
 	}
@@ -182,14 +345,32 @@ func UpdatePost(c *gin.Context) {
 
 // DeletePost 删除文章
 func DeletePost(c *gin.Context) {
-	id := c.Param("id")
-	if err := db.DB.Delete(&models.Post{}, id).Error; err != nil {
+	post, ok := loadManagedPost(c)
+	if !ok {
+		return
+	}
+	if err := db.DB.Delete(&post).Error; err != nil {
 		response.Error(c, http.StatusInternalServerError, "文章删除失败")
 		return
 	}
 	response.Ok(c, nil, "文章删除成功")
 }
 
+// loadManagedPost 加载路径中的文章并校验当前用户是否有权管理，失败时已写入响应
+func loadManagedPost(c *gin.Context) (models.Post, bool) {
+	var post models.Post
+	if err := db.DB.First(&post, c.Param("id")).Error; err != nil {
+		response.Error(c, http.StatusNotFound, "文章不存在")
+		return post, false
+	}
+	if !currentViewer(c).CanManagePost(post) {
+		response.Error(c, http.StatusForbidden, "只能管理自己的文章")
+		return post, false
+	}
+	return post, true
+}
+
+// GetPostsByTag 获取某个标签下的已发布文章
 func GetPostsByTag(c *gin.Context) {
 	tag := c.Query("tag")
 	if tag == "" {
@@ -198,78 +379,126 @@ func GetPostsByTag(c *gin.Context) {
 	}
 
 	var posts []models.Post
-	if err := db.DB.Where("tags LIKE ?", "%"+tag+"%").Find(&posts).Error; err != nil {
+	if err := db.DB.Scopes(services.PublishedPosts, services.PostsWithTags([]string{tag}, services.TagMatchAny)).
+		Omit("content", "tokens").
+		Order("adjust_time DESC").
+		Find(&posts).Error; err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取文章失败")
 		return
 	}
-	response.Ok(c, posts, "获取文章成功")
+
+	tagNames, err := services.GetTagNamesByPostIDs(postIDs(posts))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取标签失败")
+		return
+	}
+
+	list := make([]forms.PostItem, len(posts))
+	for i, p := range posts {
+		list[i] = forms.PostItem{
+			ID:          p.ID,
+			Title:       p.Title,
+			Slug:        p.Slug,
+			ImgUrl:      p.ImgUrl,
+			Tags:        tagNames[p.ID],
+			AdjustTime:  p.AdjustTime,
+			Status:      p.Status,
+			TOC:         p.TOC,
+			WordCount:   p.WordCount,
+			ReadingTime: p.ReadingTime,
+		}
+	}
+	response.Ok(c, list, "获取文章成功")
+}
+
+// SuggestPosts 搜索输入联想
+// @Summary 搜索输入联想
+// @Description 根据输入的前缀、全拼或拼音首字母返回匹配的文章标题、标签和热门搜索词
+// @Tags blog
+// @Produce json
+// @Param q query string true "输入内容"
+// @Param limit query int false "每类建议的数量"
+// @Success 200 {object} forms.SuggestResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /blog/suggest [get]
+func SuggestPosts(c *gin.Context, q forms.SuggestQuery) (*forms.SuggestResponse, error) {
+	resp, err := services.Suggest(q.Q, q.Limit)
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取搜索建议失败", err)
+	}
+	return resp, nil
 }
 
 // SearchPosts 搜索文章
 // @Summary 搜索文章
-// @Description 根据传入的参数搜索文章
+// @Description 根据传入的参数搜索文章，支持 "短语"、a | b、-排除、title:、tag:、after:、before: 语法，语法错误返回 400
 // @Tags blog
 // @Accept json
 // @Produce json
 // @Param data body forms.SearchPosts true "文章关键字"
-// @Success 200 {object} forms.PostsPage
+// @Success 200 {object} forms.SearchPage
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /search [post]
-func SearchPosts(c *gin.Context, q forms.SearchPosts) (forms.PostsPage, error) {
-	words := services.SegmentText(q.Q)
-
-	tsQuery := strings.Join(words, " & ")
-	offset := (q.Page - 1) * q.PageSize
-
-	var posts []post.SearchPost
-	var total int64
-
-	countSql := `
-		SELECT COUNT(*) 
-		FROM posts
-		WHERE tokens @@ to_tsquery('simple', ?)
-	`
-	if err := db.GetDB().Raw(countSql, tsQuery).Scan(&total).Error; err != nil {
-		return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "统计失败", err)
+func SearchPosts(c *gin.Context, q forms.SearchPosts) (forms.SearchPage, error) {
+	opts := services.SearchOptions{
+		Query:    q.Q,
+		Tags:     q.Tags,
+		TagMode:  q.TagMode,
+		Author:   q.Author,
+		Sort:     q.Sort,
+		Page:     q.Page,
+		PageSize: q.PageSize,
 	}
-
-	sql := `
-		SELECT id, title, content, tag_ids, img_url, adjust_time,
-		       ts_rank(tokens, to_tsquery('simple', ?)) AS score
-		FROM posts
-		WHERE tokens @@ to_tsquery('simple', ?)
-		ORDER BY score DESC
-		LIMIT ? OFFSET ?
-	`
-
-	if err := db.GetDB().Raw(sql, tsQuery, tsQuery, q.PageSize, offset).Scan(&posts).Error; err != nil {
-		return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "查询失败", err)
+	// from/to 已由 binding 校验格式，to 包含当天
+	if q.From != "" {
+		from, _ := time.ParseInLocation(time.DateOnly, q.From, time.Local)
+		opts.From = &from
+	}
+	if q.To != "" {
+		to, _ := time.ParseInLocation(time.DateOnly, q.To, time.Local)
+		to = to.AddDate(0, 0, 1)
+		opts.To = &to
 	}
 
-	// 构造返回数据
-	list := make([]forms.PostItem, len(posts))
-	for i, p := range posts {
-		// 获取标签名
-		tagNames, err := services.GetTagNamesByIDs(p.TagIDs)
-		if err != nil {
-			return forms.PostsPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
-		}
+	result, err := services.SearchPosts(opts)
+	if errors.Is(err, searchquery.ErrSyntax) {
+		return forms.SearchPage{}, utils.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return forms.SearchPage{}, utils.NewAPIError(http.StatusInternalServerError, "查询失败", err)
+	}
 
-		// 生成摘要
-		contentSummary := services.GenerateSummary(p.Content, tsQuery, 200, 50)
+	ids := make([]uint, len(result.Hits))
+	for i, p := range result.Hits {
+		ids[i] = p.ID
+	}
+	tagNames, err := services.GetTagNamesByPostIDs(ids)
+	if err != nil {
+		return forms.SearchPage{}, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
+	}
 
+	// 构造返回数据，标题和摘要中的命中词加上高亮标记
+	highlight := services.SearchHighlightOptions()
+	list := make([]forms.PostItem, len(result.Hits))
+	for i, p := range result.Hits {
 		list[i] = forms.PostItem{
-			ID:         p.ID,
-			Title:      p.Title,
-			ImgUrl:     p.ImgUrl,
-			Tags:       tagNames,
-			AdjustTime: p.AdjustTime,
-			Summary:    strings.ReplaceAll(contentSummary, "\r\n", ""),
+			ID:             p.ID,
+			Title:          p.Title,
+			TitleHighlight: utils.Highlight(p.Title, result.Terms, highlight),
+			Slug:           p.Slug,
+			ImgUrl:         p.ImgUrl,
+			Tags:           tagNames[p.ID],
+			AdjustTime:     p.AdjustTime,
+			Summary:        utils.HighlightSnippet(p.Content, result.Terms, highlight),
+			TOC:            p.TOC,
+			WordCount:      p.WordCount,
+			ReadingTime:    p.ReadingTime,
+			Fuzzy:          p.Fuzzy,
 		}
 	}
 
-	return forms.PostsPage{
-		Total: total,
-		List:  list,
+	return forms.SearchPage{
+		PostsPage: forms.PostsPage{Total: result.Total, List: list},
+		Facets:    result.Facets,
 	}, nil
 }
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"blog-server/forms"
+	"blog-server/services"
+	"blog-server/utils"
+	"blog-server/utils/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseRevisionVersion 解析路径中的版本号
+func parseRevisionVersion(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		response.Error(c, http.StatusBadRequest, "版本号不合法")
+		return 0, false
+	}
+	return version, true
+}
+
+// ListPostRevisions 获取文章的历史版本列表
+func ListPostRevisions(c *gin.Context) {
+	post, ok := loadManagedPost(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := services.ListPostRevisions(post.ID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取历史版本失败")
+		return
+	}
+
+	list := make([]forms.PostRevisionItem, len(revisions))
+	for i, r := range revisions {
+		list[i] = forms.PostRevisionItem{
+			Version:   r.Version,
+			Title:     r.Title,
+			Status:    r.Status,
+			Editor:    r.Editor,
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	response.Ok(c, list)
+}
+
+// GetPostRevision 获取文章的某个历史版本
+func GetPostRevision(c *gin.Context) {
+	post, ok := loadManagedPost(c)
+	if !ok {
+		return
+	}
+	version, ok := parseRevisionVersion(c)
+	if !ok {
+		return
+	}
+
+	revision, err := services.GetPostRevision(post.ID, version)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "版本不存在")
+		return
+	}
+
+	tagNames, err := services.GetTagNamesByIDs(revision.TagIDs)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取标签失败")
+		return
+	}
+
+	response.Ok(c, forms.PostRevisionResponse{
+		PostID:    revision.PostID,
+		Version:   revision.Version,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		ImgUrl:    revision.ImgUrl,
+		Tags:      tagNames,
+		Status:    revision.Status,
+		Editor:    revision.Editor,
+		CreatedAt: revision.CreatedAt,
+	})
+}
+
+// DiffPostRevisions 对比文章的两个版本
+func DiffPostRevisions(c *gin.Context) {
+	post, ok := loadManagedPost(c)
+	if !ok {
+		return
+	}
+
+	var q forms.RevisionDiffQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		response.Error(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	from, err := services.GetPostRevision(post.ID, q.From)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "版本不存在")
+		return
+	}
+	to, err := services.GetPostRevision(post.ID, q.To)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "版本不存在")
+		return
+	}
+
+	title, err := utils.DiffLines(from.Title, to.Title)
+	if err != nil {
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	content, err := utils.DiffLines(from.Content, to.Content)
+	if err != nil {
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	response.Ok(c, forms.RevisionDiffResponse{
+		From:    from.Version,
+		To:      to.Version,
+		Title:   title,
+		Content: content,
+	})
+}
+
+// RestorePostRevision 把文章恢复到某个历史版本
+func RestorePostRevision(c *gin.Context) {
+	post, ok := loadManagedPost(c)
+	if !ok {
+		return
+	}
+	version, ok := parseRevisionVersion(c)
+	if !ok {
+		return
+	}
+
+	restored, err := services.RestorePostRevision(post.ID, version, currentUsername(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, http.StatusNotFound, "文章或版本不存在")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "恢复版本失败")
+		return
+	}
+
+	response.Ok(c, restored, "版本恢复成功")
+}
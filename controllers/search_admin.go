@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services"
+	"blog-server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchDictionaryError 把自定义词和同义词相关的业务错误转换成对应的状态码
+func searchDictionaryError(err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrSynonymGroupNotFound):
+		return utils.NewAPIError(http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSearchWordInvalid), errors.Is(err, services.ErrSynonymGroupTooSmall):
+		return utils.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	return utils.NewAPIError(http.StatusInternalServerError, message, err)
+}
+
+// startReindex 按请求在后台重建索引，已有任务在运行时排队等其结束后再运行，失败不影响词典修改的结果
+func startReindex(reindex bool) bool {
+	if !reindex {
+		return false
+	}
+	if _, err := services.QueueReindex(); err != nil {
+		log.Printf("failed to start search reindex: %v", err)
+		return false
+	}
+	return true
+}
+
+// ListSearchWords 自定义词列表
+func ListSearchWords(c *gin.Context) ([]models.SearchWord, error) {
+	words, err := services.ListSearchWords()
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取自定义词失败", err)
+	}
+	return words, nil
+}
+
+// AddSearchWords 添加自定义词，返回添加后的全部自定义词
+func AddSearchWords(c *gin.Context, body forms.AddSearchWordsBody) (forms.SearchWordsResponse, error) {
+	words, err := services.AddSearchWords(body.Words)
+	if err != nil {
+		return forms.SearchWordsResponse{}, searchDictionaryError(err, "添加自定义词失败")
+	}
+	return forms.SearchWordsResponse{Words: words, Reindexing: startReindex(body.Reindex)}, nil
+}
+
+// DeleteSearchWords 删除自定义词
+func DeleteSearchWords(c *gin.Context, query forms.DeleteSearchWordsQuery) (forms.DeleteSearchWordsResponse, error) {
+	deleted, err := services.DeleteSearchWords(query.IDs)
+	if err != nil {
+		return forms.DeleteSearchWordsResponse{}, searchDictionaryError(err, "删除自定义词失败")
+	}
+	return forms.DeleteSearchWordsResponse{Deleted: deleted, Reindexing: startReindex(query.Reindex && deleted > 0)}, nil
+}
+
+// ListSynonymGroups 同义词组列表
+func ListSynonymGroups(c *gin.Context) ([]models.SynonymGroup, error) {
+	groups, err := services.ListSynonymGroups()
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取同义词失败", err)
+	}
+	return groups, nil
+}
+
+// CreateSynonymGroup 新建同义词组
+func CreateSynonymGroup(c *gin.Context, body forms.SynonymGroupBody) (*models.SynonymGroup, error) {
+	group, err := services.CreateSynonymGroup(body.Words)
+	if err != nil {
+		return nil, searchDictionaryError(err, "添加同义词失败")
+	}
+	return group, nil
+}
+
+// UpdateSynonymGroup 修改同义词组
+func UpdateSynonymGroup(c *gin.Context, body forms.SynonymGroupBody) (*models.SynonymGroup, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+	group, err := services.UpdateSynonymGroup(id, body.Words)
+	if err != nil {
+		return nil, searchDictionaryError(err, "修改同义词失败")
+	}
+	return group, nil
+}
+
+// DeleteSynonymGroup 删除同义词组
+func DeleteSynonymGroup(c *gin.Context) (string, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return "", err
+	}
+	if err := services.DeleteSynonymGroup(id); err != nil {
+		return "", searchDictionaryError(err, "删除同义词失败")
+	}
+	return "同义词已删除", nil
+}
+
+// StartReindex 开始在后台重建所有文章的搜索索引，已有任务在运行时返回 409
+func StartReindex(c *gin.Context, body forms.ReindexBody) (forms.ReindexStatus, error) {
+	status, err := services.StartReindex(body.BatchSize)
+	if errors.Is(err, services.ErrReindexRunning) {
+		return status, utils.NewAPIError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return status, utils.NewAPIError(http.StatusInternalServerError, "开始重建索引失败", err)
+	}
+	return status, nil
+}
+
+// GetReindexStatus 重建索引的进度和失败的文章
+func GetReindexStatus(c *gin.Context) (forms.ReindexStatus, error) {
+	return services.GetReindexStatus(), nil
+}
+
+// CancelReindex 取消正在运行的重建任务
+func CancelReindex(c *gin.Context) (forms.ReindexStatus, error) {
+	status, err := services.CancelReindex()
+	if err != nil {
+		return status, utils.NewAPIError(http.StatusConflict, err.Error())
+	}
+	return status, nil
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog-server/services"
+	"blog-server/utils"
+	"blog-server/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sitemapContentType = "application/xml; charset=utf-8"
+
+// Sitemap 站点地图，文章数超过单个 sitemap 上限时返回 sitemap 索引
+func Sitemap(c *gin.Context) {
+	total, lastModified, err := services.SitemapStats()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取文章失败")
+		return
+	}
+	etag := fmt.Sprintf(`W/"sitemap-%d-%d"`, total, lastModified.UnixNano())
+	if utils.SetCacheValidators(c, etag, lastModified) {
+		return
+	}
+
+	tags, err := services.SitemapTagURLs()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取标签失败")
+		return
+	}
+
+	var body []byte
+	// 首页 + 文章 + 标签都能放进一个文件时直接输出 urlset
+	if total+int64(len(tags))+1 <= services.SitemapMaxURLs {
+		var posts []services.SitemapURL
+		posts, err = services.SitemapPostURLs(0, int(total))
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "获取文章失败")
+			return
+		}
+
+		urls := []services.SitemapURL{{Loc: services.SiteURL() + "/", LastMod: lastModified}}
+		urls = append(urls, posts...)
+		urls = append(urls, tags...)
+		body, err = services.BuildSitemap(urls)
+	} else {
+		pages := (int(total) + services.SitemapMaxURLs - 1) / services.SitemapMaxURLs
+		sitemaps := make([]services.SitemapURL, 0, pages+1)
+		for page := 1; page <= pages; page++ {
+			sitemaps = append(sitemaps, services.SitemapURL{
+				Loc:     fmt.Sprintf("%s/sitemaps/posts-%d.xml", services.SiteURL(), page),
+				LastMod: lastModified,
+			})
+		}
+		sitemaps = append(sitemaps, services.SitemapURL{
+			Loc:     services.SiteURL() + "/sitemaps/tags.xml",
+			LastMod: lastModified,
+		})
+		body, err = services.BuildSitemapIndex(sitemaps)
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+
+	c.Data(http.StatusOK, sitemapContentType, body)
+}
+
+// SitemapPart sitemap 索引中的分片：posts-{n}.xml 或 tags.xml
+func SitemapPart(c *gin.Context) {
+	name := c.Param("name")
+
+	var urls []services.SitemapURL
+	var err error
+	switch {
+	case name == "tags.xml":
+		urls, err = services.SitemapTagURLs()
+	case strings.HasPrefix(name, "posts-") && strings.HasSuffix(name, ".xml"):
+		page, convErr := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "posts-"), ".xml"))
+		if convErr != nil || page < 1 {
+			response.Error(c, http.StatusNotFound, "站点地图不存在")
+			return
+		}
+		urls, err = services.SitemapPostURLs((page-1)*services.SitemapMaxURLs, services.SitemapMaxURLs)
+		if err == nil && len(urls) == 0 {
+			response.Error(c, http.StatusNotFound, "站点地图不存在")
+			return
+		}
+	default:
+		response.Error(c, http.StatusNotFound, "站点地图不存在")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+
+	body, err := services.BuildSitemap(urls)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+	c.Data(http.StatusOK, sitemapContentType, body)
+}
+
+// RobotsTxt 根据配置生成 robots.txt
+func RobotsTxt(c *gin.Context) {
+	c.String(http.StatusOK, services.BuildRobotsTxt())
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services"
+	"blog-server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tagError 把标签相关的业务错误转换成对应的状态码
+func tagError(err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		return utils.NewAPIError(http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTagExists), errors.Is(err, services.ErrTagInUse):
+		return utils.NewAPIError(http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrTagNameEmpty), errors.Is(err, services.ErrTagMergeTarget):
+		return utils.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	return utils.NewAPIError(http.StatusInternalServerError, message, err)
+}
+
+// ListTagsForAdmin 后台标签列表，包含未使用的标签
+func ListTagsForAdmin(c *gin.Context) ([]forms.AdminTagItem, error) {
+	counts, err := services.ListTagCounts(false)
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取标签失败", err)
+	}
+
+	list := make([]forms.AdminTagItem, len(counts))
+	for i, t := range counts {
+		list[i] = forms.AdminTagItem{
+			ID:          t.ID,
+			Name:        t.Name,
+			Slug:        t.Slug,
+			Description: t.Description,
+			Color:       t.Color,
+			Count:       t.Count,
+			CreatedAt:   t.CreatedAt,
+		}
+	}
+	return list, nil
+}
+
+// UpdateTag 重命名标签，或修改 slug、描述、颜色
+func UpdateTag(c *gin.Context, body forms.UpdateTagBody) (*models.Tag, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := services.UpdateTag(id, services.TagUpdate{
+		Name:        body.Name,
+		Slug:        body.Slug,
+		Description: body.Description,
+		Color:       body.Color,
+	})
+	if err != nil {
+		return nil, tagError(err, "修改标签失败")
+	}
+	return tag, nil
+}
+
+// MergeTags 合并标签
+func MergeTags(c *gin.Context, body forms.MergeTagsBody) (forms.MergeTagsResponse, error) {
+	affected, err := services.MergeTags(body.TargetID, body.SourceIDs)
+	if err != nil {
+		return forms.MergeTagsResponse{}, tagError(err, "合并标签失败")
+	}
+	return forms.MergeTagsResponse{AffectedPosts: affected}, nil
+}
+
+// DeleteTag 删除未被使用的标签
+func DeleteTag(c *gin.Context) (string, error) {
+	id, err := parseIDParam(c)
+	if err != nil {
+		return "", err
+	}
+	if err := services.DeleteTag(id); err != nil {
+		return "", tagError(err, "删除标签失败")
+	}
+	return "标签已删除", nil
+}
+
+// DeleteUnusedTags 清理所有未被使用的标签，返回删除的数量
+func DeleteUnusedTags(c *gin.Context) (int64, error) {
+	deleted, err := services.DeleteUnusedTags()
+	if err != nil {
+		return 0, utils.NewAPIError(http.StatusInternalServerError, "清理标签失败", err)
+	}
+	return deleted, nil
+}
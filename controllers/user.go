@@ -1,65 +1,162 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
-	"blog-server/config"
+	"blog-server/common/request"
 	"blog-server/forms"
+	"blog-server/models"
 	"blog-server/services"
 	"blog-server/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-const (
-	day = 24 * time.Hour
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type UserController struct{}
 
-func (u UserController) Retrieve(c *gin.Context) {
-	if c.Param("id") != "" {
-		user, err := services.GetUserByID(c.Param("id"))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error to retrieve user", "error": err})
-			c.Abort()
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"message": "User founded!", "user": user})
-		return
+// Retrieve 获取用户信息，包含手机号、邮箱等隐私字段，只有本人和管理员可以查看
+func (u UserController) Retrieve(c *gin.Context) (*models.User, error) {
+	id := c.Param("id")
+	if id != c.GetString("userID") && c.GetString("role") != models.RoleAdmin {
+		return nil, utils.NewAPIError(http.StatusForbidden, "无权查看该用户")
 	}
-	c.JSON(http.StatusBadRequest, gin.H{"message": "bad request"})
-	c.Abort()
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, utils.NewAPIError(http.StatusNotFound, "用户不存在")
+	}
+
+	user, err := services.GetUserByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.NewAPIError(http.StatusNotFound, "用户不存在")
+	}
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取用户失败", err)
+	}
+	return user, nil
 }
 
-func GenerateJWT(username string) (string, error) {
-	config := config.GetConfig()
-	jwtKey := config.GetString("server.jwtKey")
-	stringKey := []byte(jwtKey)
+// Login 使用用户名或手机号登录
+func (u UserController) Login(c *gin.Context, form forms.LoginBody) (*forms.TokenPair, error) {
+	user, err := services.Authenticate(form.Username, form.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		return nil, utils.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, services.ErrUserInactive) {
+		return nil, utils.NewAPIError(http.StatusForbidden, err.Error())
+	}
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "登录失败", err)
+	}
+
+	tokens, err := services.IssueTokenPair(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "Token 生成失败", err)
+	}
+	return tokens, nil
+}
 
-	claims := jwt.MapClaims{
-		"username": username,
-		"exp":      time.Now().Add(2 * day).Unix(), // 2 day 有效期
+// Refresh 使用 refresh token 换取新的令牌对
+func (u UserController) Refresh(c *gin.Context, form forms.RefreshTokenBody) (*forms.TokenPair, error) {
+	tokens, err := services.RefreshTokenPair(form.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+		return nil, utils.NewAPIError(http.StatusUnauthorized, err.Error())
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(stringKey)
+	if errors.Is(err, services.ErrUserInactive) {
+		return nil, utils.NewAPIError(http.StatusForbidden, err.Error())
+	}
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "Token 刷新失败", err)
+	}
+	return tokens, nil
 }
 
-func (u UserController) Login(c *gin.Context, form forms.LoginBody) (string, error) {
-	username := form.Username
-	password := form.Password
+// Logout 注销当前会话
+func (u UserController) Logout(c *gin.Context) (string, error) {
+	err := services.Logout(
+		c.GetString("userID"),
+		c.GetString("jti"),
+		c.GetString("sessionID"),
+		c.GetTime("tokenExpiresAt"),
+	)
+	if err != nil {
+		return "", utils.NewAPIError(http.StatusInternalServerError, "退出登录失败", err)
+	}
+	return "已退出登录", nil
+}
 
-	if username == "admin" && password == "123456" {
-		token, err := GenerateJWT(username)
+// ListSessions 列出当前用户的有效会话
+func (u UserController) ListSessions(c *gin.Context) ([]forms.SessionItem, error) {
+	sessions, err := services.ListActiveSessions(c.GetString("userID"))
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "获取会话失败", err)
+	}
 
-		if err != nil {
-			return "", utils.NewAPIError(http.StatusInternalServerError, "Token 生成失败", err)
+	list := make([]forms.SessionItem, len(sessions))
+	for i, s := range sessions {
+		list[i] = forms.SessionItem{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == c.GetString("sessionID"),
 		}
-		return token, nil
 	}
+	return list, nil
+}
 
-	return "", utils.NewAPIError(http.StatusBadRequest, "用户名或密码错误")
+// RevokeSession 注销当前用户的指定会话
+func (u UserController) RevokeSession(c *gin.Context) (string, error) {
+	sessionID := c.Param("id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return "", utils.NewAPIError(http.StatusNotFound, services.ErrSessionNotFound.Error())
+	}
 
+	err := services.RevokeSession(c.GetString("userID"), sessionID)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		return "", utils.NewAPIError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return "", utils.NewAPIError(http.StatusInternalServerError, "注销会话失败", err)
+	}
+	return "会话已注销", nil
+}
+
+// Register 注册新用户
+func (u UserController) Register(c *gin.Context, form request.Register) (*models.User, error) {
+	user, err := services.Signup(form)
+	if errors.Is(err, services.ErrUserExists) {
+		return nil, utils.NewAPIError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "注册失败", err)
+	}
+	return user, nil
+}
+
+// ChangePassword 修改当前登录用户的密码，其他设备上的会话会被注销
+func (u UserController) ChangePassword(c *gin.Context, form request.ChangePassword) (string, error) {
+	err := services.ChangePassword(c.GetString("userID"), c.GetString("sessionID"), form.OldPassword, form.NewPassword)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		return "", utils.NewAPIError(http.StatusBadRequest, "原密码错误")
+	}
+	if err != nil {
+		return "", utils.NewAPIError(http.StatusInternalServerError, "修改密码失败", err)
+	}
+	return "密码修改成功", nil
+}
+
+// UpdateRole 修改用户角色（仅管理员）
+func (u UserController) UpdateRole(c *gin.Context, form forms.UpdateRoleBody) (*models.User, error) {
+	user, err := services.UpdateUserRole(c.Param("id"), form.Role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.NewAPIError(http.StatusNotFound, "用户不存在")
+	}
+	if err != nil {
+		return nil, utils.NewAPIError(http.StatusInternalServerError, "修改角色失败", err)
+	}
+	return user, nil
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"blog-server/config"
 	"blog-server/models"
 	"blog-server/utils"
 	"fmt"
@@ -36,12 +37,26 @@ func InitDB() {
 	if err := DB.AutoMigrate(
 		&models.Post{},
 		&models.Tag{},
+		&models.PostTag{},
+		&models.PostRevision{},
+		&models.PostSlugRedirect{},
+		&models.User{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.Comment{},
+		&models.SpamToken{},
+		&models.SearchQuery{},
+		&models.SearchWord{},
+		&models.SynonymGroup{},
 	); err != nil {
 		log.Fatal("failed to migrate database:", err)
 	}
 
 	// 调用 EnsureGinIndex 创建扩展和索引
 	EnsureGinIndex()
+	EnsureSlugIndex()
+	MigratePostTags()
 
 	utils.Log("Database initialized.")
 }
@@ -52,6 +67,11 @@ func EnsureGinIndex() {
 		"CREATE EXTENSION IF NOT EXISTS pg_trgm;",
 		//  posts 表 tokens 字段
 		"CREATE INDEX IF NOT EXISTS idx_posts_tokens ON posts USING GIN(tokens);",
+		// 标题 trigram 索引，用于搜索的模糊匹配
+		"CREATE INDEX IF NOT EXISTS idx_posts_title_trgm ON posts USING GIN(title gin_trgm_ops);",
+	}
+	if config.GetConfig().GetBool("search.fuzzy.content") {
+		sqls = append(sqls, "CREATE INDEX IF NOT EXISTS idx_posts_content_trgm ON posts USING GIN(content gin_trgm_ops);")
 	}
 
 	for _, sql := range sqls {
@@ -63,6 +83,56 @@ func EnsureGinIndex() {
 	utils.Log("PG GIN index and extension ensured.")
 }
 
+// EnsureSlugIndex 确保文章 slug 唯一（老文章回填前 slug 为空，不参与唯一约束）
+func EnsureSlugIndex() {
+	sql := "CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug) WHERE slug <> '';"
+	if err := DB.Exec(sql).Error; err != nil {
+		log.Fatalf("failed to execute %q: %v", sql, err)
+	}
+}
+
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// MigratePostTags 把旧版 posts.tag_ids 数组迁移到 post_tags 关联表，完成后删除该列
+func MigratePostTags() {
+	if !DB.Migrator().HasColumn("posts", "tag_ids") {
+		return
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// 数组下标作为标签顺序，已不存在的标签直接丢弃
+		sql := `
+			INSERT INTO post_tags (post_id, tag_id, position)
+			SELECT p.id, t.tag_id, t.ord - 1
+			FROM posts p
+			CROSS JOIN LATERAL unnest(p.tag_ids) WITH ORDINALITY AS t(tag_id, ord)
+			JOIN tags ON tags.id = t.tag_id
+			ON CONFLICT DO NOTHING
+		`
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn("posts", "tag_ids")
+	})
+	if err != nil {
+		log.Fatal("failed to migrate post tags:", err)
+	}
+
+	utils.Log("Post tags migrated to post_tags.")
+}
+
+// EnsureTagIndexes 确保规范化后的标签名和标签 slug 唯一
+// 需要在 services.NormalizeTags 合并重复标签之后调用
+func EnsureTagIndexes() {
+	sqls := []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name_key ON tags(name_key) WHERE name_key <> '' AND deleted_at IS NULL;",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug ON tags(slug) WHERE slug <> '' AND deleted_at IS NULL;",
+	}
+	for _, sql := range sqls {
+		if err := DB.Exec(sql).Error; err != nil {
+			log.Fatalf("failed to execute %q: %v", sql, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package forms
+
+import "time"
+
+type FetchCommentsQuery struct {
+	Page     int `form:"page" binding:"required,min=1"`
+	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+}
+
+// CreateCommentBody 登录用户可以不填作者信息，匿名读者必须填写昵称和邮箱
+type CreateCommentBody struct {
+	ParentID      *uint  `json:"parentId"`
+	AuthorName    string `json:"authorName" binding:"omitempty,max=100"`
+	AuthorEmail   string `json:"authorEmail" binding:"omitempty,email,max=255"`
+	AuthorWebsite string `json:"authorWebsite" binding:"omitempty,url,max=255"`
+	Content       string `json:"content" binding:"required,max=5000"`
+	// 前端隐藏的蜜罐字段，必须留空
+	Homepage string `json:"homepage"`
+	// 通过 comments/token 接口获取的表单令牌
+	FormToken string `json:"formToken"`
+}
+
+type CommentFormToken struct {
+	Token string `json:"token"`
+}
+
+// CommentItem 公开展示的评论，不包含邮箱和 IP
+type CommentItem struct {
+	ID            uint      `json:"id"`
+	ParentID      *uint     `json:"parentId"`
+	AuthorName    string    `json:"authorName"`
+	AuthorWebsite string    `json:"authorWebsite"`
+	Content       string    `json:"content"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// 已删除或未通过审核，只为展示其下已通过的回复而保留，作者和内容为空
+	Deleted bool          `json:"deleted"`
+	Replies []CommentItem `json:"replies"`
+}
+
+// CommentsPage 按顶层评论分页，每条顶层评论带上它的全部回复
+type CommentsPage struct {
+	Total int64         `json:"total"`
+	List  []CommentItem `json:"list"`
+}
+
+// CreateCommentResponse 新评论待审核时 Status 为 pending
+type CreateCommentResponse struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+}
+
+type ModerationCommentsQuery struct {
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"pageSize" binding:"required,min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending approved spam deleted"`
+	PostID   uint   `form:"postId"`
+}
+
+// ModerationCommentItem 审核列表项，包含作者联系方式和来源
+type ModerationCommentItem struct {
+	ID            uint      `json:"id"`
+	PostID        uint      `json:"postId"`
+	ParentID      *uint     `json:"parentId"`
+	UserID        string    `json:"userId"`
+	AuthorName    string    `json:"authorName"`
+	AuthorEmail   string    `json:"authorEmail"`
+	AuthorWebsite string    `json:"authorWebsite"`
+	Content       string    `json:"content"`
+	Status        string    `json:"status"`
+	SpamReason    string    `json:"spamReason"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"userAgent"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type ModerationCommentsPage struct {
+	Total int64                   `json:"total"`
+	List  []ModerationCommentItem `json:"list"`
+}
+
+type UpdateCommentBody struct {
+	AuthorName    string `json:"authorName" binding:"omitempty,max=100"`
+	AuthorWebsite string `json:"authorWebsite" binding:"omitempty,url,max=255"`
+	Content       string `json:"content" binding:"required,max=5000"`
+}
+
+type DeleteCommentsQuery struct {
+	IDs []uint `form:"ids" binding:"required,min=1,max=100"`
+}
@@ -4,6 +4,7 @@ package forms
 type NewsItem struct {
 	ID          uint     `json:"id"`
 	Title       string   `json:"title"`
+	Slug        string   `json:"slug"`
 	Description string   `json:"description"`
 	Tags        []string `json:"tags"`
 	ImgUrl      string   `json:"img_url"`
@@ -1,6 +1,6 @@
 package forms
 
 type LoginBody struct {
-	Username string `json:"username" binding:"required,min=3"`
+	Username string `json:"username" binding:"required,min=3"` // 用户名或手机号
 	Password string `json:"password" binding:"required,min=6"`
 }
@@ -1,9 +1,20 @@
 package forms
 
-import "time"
+import (
+	"time"
+
+	"blog-server/utils"
+)
+
+// 正文格式：markdown 返回 gzip+base64 压缩的原文（默认），html 返回渲染后的 HTML
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
+)
 
 type FetchPostQuery struct {
-	Seq int `form:"seq" binding:"required"`
+	Seq    int    `form:"seq" binding:"required"`
+	Format string `form:"format" binding:"omitempty,oneof=markdown html"`
 }
 
 type CreatePostBody struct {
@@ -11,29 +22,54 @@ type CreatePostBody struct {
 	Content string   `json:"content" binding:"required"`
 	Tags    []string `json:"tags" binding:"required"` // JSON 数组
 	ImgUrl  string   `json:"imgUrl" binding:"required"`
+	// 手动指定 slug，为空时根据标题生成
+	Slug string `json:"slug" binding:"omitempty,max=200"`
+	// 文章状态，默认 published；scheduled 时必须提供 publishAt
+	Status    string     `json:"status" binding:"omitempty,oneof=draft published scheduled archived"`
+	PublishAt *time.Time `json:"publishAt"`
 }
 
 type PostResponse struct {
 	ID         uint     `json:"id"`
 	Title      string   `json:"title"`
+	Slug       string   `json:"slug"`
 	ImgUrl     string   `json:"imgUrl"`
 	Content    string   `json:"content"`
+	Format     string   `json:"format"` // content 的格式
 	AdjustTime string   `json:"adjustTime"`
 	Tags       []string `json:"tags"`
+	Status     string   `json:"status"`
+	PublishAt  string   `json:"publishAt,omitempty"`
+	// 标题目录，id 与 format=html 时渲染出的锚点一致
+	TOC         []utils.Heading `json:"toc"`
+	WordCount   int             `json:"wordCount"`
+	ReadingTime int             `json:"readingTime"` // 预计阅读分钟数
 }
 
 type FetchPostsQuery struct {
 	Page     int `form:"page" binding:"required,min=1"`
 	PageSize int `form:"pageSize" binding:"required,min=1,max=100"`
+	// 仅登录的编辑可用，未登录时始终只返回已发布文章
+	Status string `form:"status" binding:"omitempty,oneof=draft published scheduled archived"`
+	// 按标签筛选，可重复传参或用逗号分隔；tagMode 为 any（默认，任一标签）或 all（全部标签）
+	Tags    []string `form:"tags"`
+	TagMode string   `form:"tagMode" binding:"omitempty,oneof=any all"`
 }
 
 type PostItem struct {
-	ID         uint      `json:"id"`
-	Title      string    `json:"title"`
-	ImgUrl     string    `json:"imgUrl"`
-	Tags       []string  `json:"tags"`
-	AdjustTime time.Time `json:"adjustTime"` // 格式化后的时间
-	Summary    string    `json:"summary"`
+	ID             uint            `json:"id"`
+	Title          string          `json:"title"`
+	TitleHighlight string          `json:"titleHighlight,omitempty"` // 搜索结果中标记了命中词的标题
+	Slug           string          `json:"slug"`
+	ImgUrl         string          `json:"imgUrl"`
+	Tags           []string        `json:"tags"`
+	AdjustTime     time.Time       `json:"adjustTime"` // 格式化后的时间
+	Summary        string          `json:"summary"`    // 搜索结果中为高亮后的正文片段
+	Status         string          `json:"status"`
+	TOC            []utils.Heading `json:"toc"` // 与详情接口相同的标题目录
+	WordCount      int             `json:"wordCount"`
+	ReadingTime    int             `json:"readingTime"`     // 预计阅读分钟数
+	Fuzzy          bool            `json:"fuzzy,omitempty"` // 搜索结果只被模糊匹配命中
 }
 
 type PostsPage struct {
@@ -42,7 +78,57 @@ type PostsPage struct {
 }
 
 type SearchPosts struct {
-	Q        string `form:"q"`
+	Q        string `form:"q"` // 查询语法见 services/searchquery
 	Page     int    `form:"page" binding:"required,min=1"`
 	PageSize int    `form:"pageSize" binding:"required,min=1,max=100"`
+	// 同 FetchPostsQuery
+	Tags    []string `form:"tags"`
+	TagMode string   `form:"tagMode" binding:"omitempty,oneof=any all"`
+	// 作者的用户名或用户 ID
+	Author string `form:"author"`
+	// 按发布时间筛选，包含当天
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	// relevance 按相关度（默认），newest / oldest 按发布时间
+	Sort string `form:"sort" binding:"omitempty,oneof=relevance newest oldest"`
+}
+
+// 搜索结果排序方式
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortNewest    = "newest"
+	SearchSortOldest    = "oldest"
+)
+
+// TagFacet 搜索结果在某个标签下的文章数
+type TagFacet struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+// MonthFacet 搜索结果在某个月份（如 2024-05）发布的文章数
+type MonthFacet struct {
+	Month string `json:"month"`
+	Count int64  `json:"count"`
+}
+
+// SearchFacets 整个搜索结果（不只是当前页）按标签和月份的统计，用于筛选侧栏
+type SearchFacets struct {
+	Tags   []TagFacet   `json:"tags"`
+	Months []MonthFacet `json:"months"`
+}
+
+type SearchPage struct {
+	PostsPage
+	Facets SearchFacets `json:"facets"`
+}
+
+// TagItem 标签及其下已发布文章数
+type TagItem struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Count       int64  `json:"count"`
 }
@@ -1,8 +0,0 @@
-package post
-
-import "blog-server/models"
-
-type SearchPost struct {
-	models.Post
-	Score float64
-}
@@ -0,0 +1,40 @@
+package forms
+
+import (
+	"time"
+
+	"blog-server/utils"
+)
+
+type RevisionDiffQuery struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"required,min=1"`
+}
+
+// PostRevisionItem 版本列表项（不含正文）
+type PostRevisionItem struct {
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PostRevisionResponse struct {
+	PostID    uint      `json:"postId"`
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	ImgUrl    string    `json:"imgUrl"`
+	Tags      []string  `json:"tags"`
+	Status    string    `json:"status"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RevisionDiffResponse struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Title   []utils.DiffLine `json:"title"`
+	Content []utils.DiffLine `json:"content"`
+}
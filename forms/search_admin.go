@@ -0,0 +1,67 @@
+package forms
+
+import (
+	"time"
+
+	"blog-server/models"
+)
+
+// AddSearchWordsBody 添加分词自定义词，如 "k8s"、"云原生"；reindex 为 true 时在后台重建所有文章的索引
+type AddSearchWordsBody struct {
+	Words   []string `json:"words" binding:"required,min=1,max=100,dive,required,max=50"`
+	Reindex bool     `json:"reindex"`
+}
+
+type SearchWordsResponse struct {
+	Words      []models.SearchWord `json:"words"`
+	Reindexing bool                `json:"reindexing"` // 是否开始了后台重建索引，已有任务在运行时排在其后
+}
+
+type DeleteSearchWordsQuery struct {
+	IDs     []uint `form:"ids" binding:"required,min=1,max=100"`
+	Reindex bool   `form:"reindex"`
+}
+
+type DeleteSearchWordsResponse struct {
+	Deleted    int64 `json:"deleted"`
+	Reindexing bool  `json:"reindexing"`
+}
+
+// SynonymGroupBody 一组同义词，如 ["k8s", "kubernetes"]，搜索时互相展开，不需要重建索引
+type SynonymGroupBody struct {
+	Words []string `json:"words" binding:"required,min=2,max=20,dive,required,max=50"`
+}
+
+// 重建搜索索引任务的状态
+const (
+	ReindexStateIdle      = "idle" // 服务启动后还没有运行过
+	ReindexStateRunning   = "running"
+	ReindexStateCompleted = "completed"
+	ReindexStateCancelled = "cancelled"
+	ReindexStateFailed    = "failed" // 读取文章失败，单篇文章失败只计入 failed
+)
+
+// ReindexBody 重建搜索索引，batchSize 为空时使用 search.reindex.batchSize
+type ReindexBody struct {
+	BatchSize int `json:"batchSize" form:"batchSize" binding:"omitempty,min=1,max=1000"`
+}
+
+// ReindexError 生成索引失败的文章
+type ReindexError struct {
+	PostID uint   `json:"postId"`
+	Error  string `json:"error"`
+}
+
+// ReindexStatus 当前或最近一次重建任务的进度，errors 最多保留 100 条
+type ReindexStatus struct {
+	State      string         `json:"state"`
+	Message    string         `json:"message,omitempty"` // 任务失败的原因
+	BatchSize  int            `json:"batchSize,omitempty"`
+	Total      int64          `json:"total"` // 开始时的文章数
+	Processed  int64          `json:"processed"`
+	Failed     int64          `json:"failed"`
+	Errors     []ReindexError `json:"errors"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Queued     bool           `json:"queued"` // 词典在任务运行期间被修改，当前任务结束后会再运行一次
+}
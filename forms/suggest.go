@@ -0,0 +1,25 @@
+package forms
+
+type SuggestQuery struct {
+	Q     string `form:"q" binding:"required,max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"` // 每类建议的数量，默认 5
+}
+
+type SuggestPost struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+type SuggestTag struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+// SuggestResponse 输入联想结果，依次为匹配的文章标题、标签和热门搜索词
+type SuggestResponse struct {
+	Posts   []SuggestPost `json:"posts"`
+	Tags    []SuggestTag  `json:"tags"`
+	Queries []string      `json:"queries"`
+}
@@ -0,0 +1,32 @@
+package forms
+
+import "time"
+
+// AdminTagItem 后台标签列表项，Count 包含草稿等所有状态的文章
+type AdminTagItem struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Color       string    `json:"color"`
+	Count       int64     `json:"count"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// UpdateTagBody 只修改传入的字段；name 与其他标签规范化后重名时需要改用合并
+type UpdateTagBody struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Slug        *string `json:"slug" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	Color       *string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// MergeTagsBody 把 sourceIds 合并到 targetId
+type MergeTagsBody struct {
+	TargetID  uint   `json:"targetId" binding:"required"`
+	SourceIDs []uint `json:"sourceIds" binding:"required,min=1,max=100"`
+}
+
+type MergeTagsResponse struct {
+	AffectedPosts int `json:"affectedPosts"`
+}
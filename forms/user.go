@@ -6,3 +6,7 @@ type UserSignup struct {
 	Gender   string `json:"gender" binding:"required"`
 	PhotoURL string `json:"photo_url" binding:"required"`
 }
+
+type UpdateRoleBody struct {
+	Role string `json:"role" binding:"required,oneof=admin editor author reader"`
+}
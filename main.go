@@ -8,6 +8,8 @@ import (
 	"blog-server/config"
 	"blog-server/db"
 	"blog-server/server"
+	"blog-server/services"
+	"blog-server/services/token"
 	"log"
 
 	"github.com/joho/godotenv"
@@ -39,6 +41,30 @@ func main() {
 		log.Fatal("加载 .env 文件失败: ", err)
 	}
 
+	if err := token.Init(); err != nil {
+		log.Fatal("加载 JWT 密钥失败: ", err)
+	}
+
+	// 提前加载分词词典，避免第一次搜索时等待
+	services.SearchTokenizer()
+
 	db.InitDB()
+	if err := services.BackfillPostSlugs(); err != nil {
+		log.Fatal("生成文章 slug 失败: ", err)
+	}
+	if err := services.BackfillPostStats(); err != nil {
+		log.Fatal("统计文章字数失败: ", err)
+	}
+	if err := services.NormalizeTags(); err != nil {
+		log.Fatal("合并重复标签失败: ", err)
+	}
+	db.EnsureTagIndexes()
+	if err := services.ReloadSearchDictionary(); err != nil {
+		log.Fatal("加载搜索自定义词失败: ", err)
+	}
+	if err := services.ReindexStalePosts(); err != nil {
+		log.Fatal("重建搜索索引失败: ", err)
+	}
+	services.StartPostScheduler(config.GetConfig().GetDuration("scheduler.interval"))
 	server.Init()
 }
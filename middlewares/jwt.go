@@ -1,45 +1,91 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
+	"blog-server/services/token"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var errMissingToken = errors.New("缺少或无效的 Authorization 头")
+
+// parseBearerToken 解析 Authorization 头中的 Bearer token
+func parseBearerToken(c *gin.Context) (jwt.MapClaims, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, errMissingToken
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	return token.Parse(tokenString)
+}
+
 func JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少或无效的 Authorization 头"})
+		claims, err := parseBearerToken(c)
+		if errors.Is(err, errMissingToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
-
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		jwtKey := os.Getenv("jwtKey")
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// 检查签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtKey, nil
-		})
-
-		if err != nil || !token.Valid {
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 token"})
 			c.Abort()
 			return
 		}
 
-		// 将用户信息传入 context（可选）
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("username", claims["username"])
+		revoked, err := token.IsRevoked(claimString(claims, "jti"), claimString(claims, "sid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "校验 token 失败"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token 已失效"})
+			c.Abort()
+			return
 		}
 
+		// 将用户信息传入 context（可选）
+		setUserContext(c, claims)
+
+		c.Next()
+	}
+}
+
+// OptionalJWTMiddleware 携带有效 token 时写入用户信息，否则按匿名用户继续处理
+func OptionalJWTMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, err := parseBearerToken(c); err == nil {
+			revoked, err := token.IsRevoked(claimString(claims, "jti"), claimString(claims, "sid"))
+			if err == nil && !revoked {
+				setUserContext(c, claims)
+			}
+		}
 		c.Next()
 	}
 }
+
+func setUserContext(c *gin.Context, claims jwt.MapClaims) {
+	c.Set("username", claims["username"])
+	if sub, err := claims.GetSubject(); err == nil {
+		c.Set("userID", sub)
+	}
+	if role, ok := claims["role"].(string); ok {
+		c.Set("role", role)
+	}
+	c.Set("jti", claimString(claims, "jti"))
+	c.Set("sessionID", claimString(claims, "sid"))
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		c.Set("tokenExpiresAt", exp.Time)
+	}
+}
+
+func claimString(claims jwt.MapClaims, key string) string {
+	value, _ := claims[key].(string)
+	return value
+}
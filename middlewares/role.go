@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户拥有指定角色之一，需放在 JWTMiddleware 之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("role")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "没有权限执行该操作"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+// 评论状态
+const (
+	CommentStatusPending  = "pending"  // 待审核，只有管理员可见
+	CommentStatusApproved = "approved" // 审核通过，公开展示
+	CommentStatusSpam     = "spam"     // 被拒绝或判定为垃圾评论
+	CommentStatusDeleted  = "deleted"  // 已删除，保留记录以维持楼层结构
+)
+
+// Comment 文章评论，ParentID 为空时是顶层评论，RootID 指向所在楼层的顶层评论
+type Comment struct {
+	ID       uint  `gorm:"primaryKey" json:"id"`
+	PostID   uint  `gorm:"not null;index" json:"post_id"`
+	ParentID *uint `gorm:"index" json:"parent_id"`
+	RootID   uint  `gorm:"not null;default:0;index" json:"root_id"`
+	// 登录用户的 ID，匿名读者为空
+	UserID        string `gorm:"size:36;not null;default:'';index" json:"user_id"`
+	AuthorName    string `gorm:"size:100;not null" json:"author_name"`
+	AuthorEmail   string `gorm:"size:255;not null;default:''" json:"author_email"`
+	AuthorWebsite string `gorm:"size:255;not null;default:''" json:"author_website"`
+	Content       string `gorm:"type:text;not null" json:"content"`
+	Status        string `gorm:"size:20;not null;default:pending;index" json:"status"`
+	IP            string `gorm:"size:64" json:"ip"`
+	UserAgent     string `gorm:"size:255" json:"user_agent"`
+	// 反垃圾检查给出的原因，方便审核员判断
+	SpamReason string `gorm:"size:255;not null;default:''" json:"spam_reason"`
+	// 上一次反馈给分类器的结论（spam/ham），审核结论改判时用于撤销
+	SpamTrainedAs string `gorm:"size:10;not null;default:''" json:"spam_trained_as"`
+	Timestamps
+}
@@ -1,19 +1,65 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
-	"github.com/lib/pq"
+	"blog-server/utils"
+)
+
+// 文章状态
+const (
+	PostStatusDraft     = "draft"     // 草稿，仅编辑可见
+	PostStatusPublished = "published" // 已发布
+	PostStatusScheduled = "scheduled" // 定时发布，到 PublishAt 后自动转为已发布
+	PostStatusArchived  = "archived"  // 已归档，不再公开展示
 )
 
 type Post struct {
-	ID         uint          `gorm:"primaryKey" json:"id"`
-	Title      string        `gorm:"size:255;not null" json:"title"`
-	Content    string        `gorm:"type:text;not null" json:"content"`
-	ImgUrl     string        `gorm:"size:255" json:"img_url"`
-	TagIDs     pq.Int64Array `gorm:"type:integer[]" json:"tag_ids"`
-	AdjustTime time.Time     `gorm:"default:CURRENT_TIMESTAMP" json:"adjust_time"`
-	Tokens     string        `gorm:"type:tsvector" json:"-"`
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Title      string    `gorm:"size:255;not null" json:"title"`
+	Slug       string    `gorm:"size:255;not null;default:''" json:"slug"` // 唯一索引见 db.EnsureSlugIndex
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	ImgUrl     string    `gorm:"size:255" json:"img_url"`
+	AdjustTime time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"adjust_time"`
+	Tokens     string    `gorm:"type:tsvector" json:"-"`
+	// 生成 tokens 时的分词方式，与 services.SearchIndexVersion 不同时启动后会重建索引
+	TokensVersion string     `gorm:"size:100;not null;default:''" json:"-"`
+	AuthorID      string     `gorm:"size:36;not null;default:'';index" json:"author_id"` // models.User.ID，老文章为空
+	Status        string     `gorm:"size:20;not null;default:published;index" json:"status"`
+	PublishAt     *time.Time `gorm:"index" json:"publish_at"`
+	// 保存文章时根据正文计算，列表接口无需加载正文
+	TOC         PostTOC `gorm:"type:jsonb;not null;default:'[]'" json:"toc"`
+	WordCount   int     `gorm:"not null;default:0" json:"word_count"`
+	ReadingTime int     `gorm:"not null;default:0" json:"reading_time"` // 分钟
+	// 计算上述统计信息时的算法版本，低于当前版本的文章在启动时重新计算
+	StatsVersion int `gorm:"not null;default:0" json:"-"`
 
 	Timestamps
 }
+
+// PostTOC 文章目录，以 JSON 保存
+type PostTOC []utils.Heading
+
+func (t PostTOC) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(t)
+	return string(b), err
+}
+
+func (t *PostTOC) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return errors.New("PostTOC: unsupported scan type")
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostRevision 文章的历史版本，每次创建/更新/恢复文章时写入一条
+type PostRevision struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	PostID    uint          `gorm:"not null;uniqueIndex:idx_post_revisions_post_version" json:"post_id"`
+	Version   int           `gorm:"not null;uniqueIndex:idx_post_revisions_post_version" json:"version"`
+	Title     string        `gorm:"size:255;not null" json:"title"`
+	Content   string        `gorm:"type:text;not null" json:"content"`
+	ImgUrl    string        `gorm:"size:255" json:"img_url"`
+	TagIDs    pq.Int64Array `gorm:"type:integer[]" json:"tag_ids"`
+	Status    string        `gorm:"size:20" json:"status"`
+	Editor    string        `gorm:"size:100" json:"editor"`
+	CreatedAt time.Time     `json:"created_at"`
+}
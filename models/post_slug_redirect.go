@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PostSlugRedirect 记录文章改名前的旧 slug，旧链接会 301 跳转到文章当前的 slug
+type PostSlugRedirect struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OldSlug   string    `gorm:"size:255;not null;uniqueIndex" json:"old_slug"`
+	PostID    uint      `gorm:"not null;index" json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// PostTag 文章和标签的多对多关系，Position 保存标签在文章中的顺序
+type PostTag struct {
+	PostID   uint `gorm:"primaryKey" json:"post_id"`
+	TagID    uint `gorm:"primaryKey;index" json:"tag_id"`
+	Position int  `gorm:"not null;default:0" json:"position"`
+}
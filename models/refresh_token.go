@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌，只保存哈希；使用一次后即被轮换
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	SessionID string     `gorm:"type:uuid;not null;index" json:"session_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // 已轮换的令牌再次出现说明被盗用
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RevokedToken 已吊销的 access token（按 jti），过期后可以清理
+type RevokedToken struct {
+	JTI       string    `gorm:"size:36;primaryKey" json:"jti"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SearchWord 搜索分词的自定义词，分词时作为一个整体，不会被词典切开
+type SearchWord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Word      string    `gorm:"size:50;uniqueIndex;not null" json:"word"` // 小写
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SynonymGroup 一组同义词，搜索其中任意一个词时也会匹配其他词
+type SynonymGroup struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Words     pq.StringArray `gorm:"type:text[];not null" json:"words"` // 小写，至少两个
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SearchQuery 搜索过的关键词及次数，用于搜索建议中的热门搜索
+type SearchQuery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Query          string    `gorm:"size:100;uniqueIndex;not null" json:"query"` // 规范化后的关键词
+	Count          int64     `gorm:"not null;default:0;index" json:"count"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// SpamToken 垃圾评论贝叶斯分类器的词频，记录每个词出现在多少篇垃圾/正常评论中
+type SpamToken struct {
+	Token string `gorm:"size:160;primaryKey" json:"token"`
+	Spam  int    `gorm:"not null;default:0" json:"spam"`
+	Ham   int    `gorm:"not null;default:0" json:"ham"`
+}
@@ -3,5 +3,10 @@ package models
 type Tag struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	// utils.TagNameKey(Name)，唯一索引见 db.EnsureTagIndexes
+	NameKey     string `gorm:"size:100;not null;default:''" json:"-"`
+	Slug        string `gorm:"size:120;not null;default:''" json:"slug"` // 拼音 slug，唯一索引见 db.EnsureTagIndexes
+	Description string `gorm:"size:500;not null;default:''" json:"description"`
+	Color       string `gorm:"size:20;not null;default:''" json:"color"` // 如 #00ADD8
 	Timestamps
 }
@@ -1,13 +1,25 @@
 package models
 
+// 用户角色
+const (
+	RoleAdmin  = "admin"  // 管理员：管理所有文章和用户
+	RoleEditor = "editor" // 编辑：管理所有文章
+	RoleAuthor = "author" // 作者：只能管理自己的文章
+	RoleReader = "reader" // 读者：只能浏览已发布文章
+)
+
 type User struct {
-	ID       string `gorm:"type:uuid;primaryKey" json:"user_id"`
-	Name     string `gorm:"size:100;not null" json:"name"`
-	BirthDay string `gorm:"size:20" json:"birthday"`
-	Gender   string `gorm:"size:10" json:"gender"`
-	PhotoURL string `gorm:"size:255" json:"photo_url"`
-	Time     int64  `json:"current_time"`
-	Active   bool   `json:"active"`
+	ID           string `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Name         string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Mobile       string `gorm:"size:20;not null;default:'';uniqueIndex" json:"mobile"`
+	Email        string `gorm:"size:255;not null;default:''" json:"email"`
+	PasswordHash string `gorm:"size:255;not null;default:''" json:"-"`
+	Role         string `gorm:"size:20;not null;default:reader;index" json:"role"`
+	BirthDay     string `gorm:"size:20" json:"birthday"`
+	Gender       string `gorm:"size:10" json:"gender"`
+	PhotoURL     string `gorm:"size:255" json:"photo_url"`
+	Time         int64  `json:"current_time"`
+	Active       bool   `json:"active"`
 
 	Timestamps
 }
@@ -1,8 +1,12 @@
 package server
 
 import (
+	"log"
+
+	"blog-server/config"
 	"blog-server/controllers"
 	"blog-server/middlewares"
+	"blog-server/models"
 	"blog-server/utils"
 	"time"
 
@@ -13,6 +17,10 @@ import (
 
 func NewRouter() *gin.Engine {
 	router := gin.New()
+	// 只信任这些代理传来的 X-Forwarded-For，否则 ClientIP 可以被请求头伪造，评论限流按 IP 计算
+	if err := router.SetTrustedProxies(config.GetConfig().GetStringSlice("server.trustedProxies")); err != nil {
+		log.Fatal("server.trustedProxies 配置错误: ", err)
+	}
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(cors.New(cors.Config{
@@ -37,17 +45,42 @@ func NewRouter() *gin.Engine {
 	router.GET("/health", health.Status)
 	// router.Use(middlewares.AuthMiddleware())
 
+	// 订阅
+	router.GET("/feed.xml", controllers.RSSFeed)
+	router.GET("/atom.xml", controllers.AtomFeed)
+	router.GET("/feed.json", controllers.JSONFeed)
+	router.GET("/tags/:name/feed.xml", controllers.TagRSSFeed)
+
+	// SEO
+	router.GET("/sitemap.xml", controllers.Sitemap)
+	router.GET("/sitemaps/:name", controllers.SitemapPart)
+	router.GET("/robots.txt", controllers.RobotsTxt)
+
+	// JWT 公钥，供其他服务校验本站签发的 token
+	router.GET("/.well-known/jwks.json", controllers.JWKS)
+
 	api := router.Group("api")
 	{
 		userGroup := api.Group("user")
 		{
 			user := new(controllers.UserController)
 			userGroup.POST("/login", utils.BindAndRespondR(user.Login))
+			userGroup.POST("/register", utils.BindAndRespondR(user.Register))
+			userGroup.POST("/refresh", utils.BindAndRespondR(user.Refresh))
+			userGroup.POST("/logout", middlewares.JWTMiddleware(), utils.BindAndRespond(user.Logout))
+			userGroup.PUT("/password", middlewares.JWTMiddleware(), utils.BindAndRespondR(user.ChangePassword))
+			userGroup.GET("/sessions", middlewares.JWTMiddleware(), utils.BindAndRespond(user.ListSessions))
+			userGroup.DELETE("/sessions/:id", middlewares.JWTMiddleware(), utils.BindAndRespond(user.RevokeSession))
 
 			// router.Use(middlewares.JWTMiddleware())
 			// userGroup.Use(middlewares.JWTMiddleware())
 
-			userGroup.GET("/:id", user.Retrieve)
+			userGroup.GET("/:id", middlewares.JWTMiddleware(), utils.BindAndRespond(user.Retrieve))
+			userGroup.PUT("/:id/role",
+				middlewares.JWTMiddleware(),
+				middlewares.RequireRole(models.RoleAdmin),
+				utils.BindAndRespondR(user.UpdateRole),
+			)
 		}
 
 		// 首页接口
@@ -56,28 +89,91 @@ func NewRouter() *gin.Engine {
 			homeGroup.GET("/get-news", controllers.GetNews) // 获取首页文章列表
 		}
 
-		// 文章相关路由（暂时不需要认证，方便测试）
+		// 文章相关路由，写操作需要作者及以上角色
 		postGroup := api.Group("blog")
 		{
+			canWrite := middlewares.RequireRole(models.RoleAdmin, models.RoleEditor, models.RoleAuthor)
+
 			// 搜索文章
 			postGroup.GET("/search", utils.BindAndRespondR(controllers.SearchPosts))
-			postGroup.GET("/query-blog", utils.BindAndRespondR(controllers.GetPosts))
+			// 搜索输入联想，支持拼音和拼音首字母
+			postGroup.GET("/suggest", utils.BindAndRespondR(controllers.SuggestPosts))
+			// 携带 token 的编辑可以看到草稿等未发布文章，作者可以看到自己的草稿
+			postGroup.GET("/query-blog", middlewares.OptionalJWTMiddleware(), utils.BindAndRespondR(controllers.GetPosts))
 
 			// 根据标签获取文章
 			postGroup.GET("/tag", controllers.GetPostsByTag)
 			postGroup.GET("/get-tags", controllers.GetTags)
 			// 获取单篇文章
 			postGroup.GET("/fetch-blog-by-seq",
+				middlewares.OptionalJWTMiddleware(),
 				utils.BindAndRespondR(controllers.GetPost),
 			)
 
+			// 根据 slug 获取单篇文章
+			postGroup.GET("/by-slug/:slug", middlewares.OptionalJWTMiddleware(), controllers.GetPostBySlug)
+
 			// 创建文章
-			postGroup.POST("", utils.BindAndRespondR(controllers.CreatePost))
+			postGroup.POST("", middlewares.JWTMiddleware(), canWrite, utils.BindAndRespondR(controllers.CreatePost))
 
 			// 更新文章
-			postGroup.PUT("/:id", middlewares.JWTMiddleware(), controllers.UpdatePost)
+			postGroup.PUT("/:id", middlewares.JWTMiddleware(), canWrite, controllers.UpdatePost)
 			// 删除文章
-			postGroup.DELETE("/:id", middlewares.JWTMiddleware(), controllers.DeletePost)
+			postGroup.DELETE("/:id", middlewares.JWTMiddleware(), canWrite, controllers.DeletePost)
+
+			// 文章历史版本
+			revisionGroup := postGroup.Group("/:id/revisions", middlewares.JWTMiddleware(), canWrite)
+			{
+				revisionGroup.GET("", controllers.ListPostRevisions)
+				revisionGroup.GET("/diff", controllers.DiffPostRevisions)
+				revisionGroup.GET("/:version", controllers.GetPostRevision)
+				revisionGroup.POST("/:version/restore", controllers.RestorePostRevision)
+			}
+
+			// 评论，登录用户发表评论时使用自己的账号，提交前需要先获取表单令牌
+			postGroup.GET("/:id/comments", utils.BindAndRespondR(controllers.GetPostComments))
+			postGroup.GET("/:id/comments/token", utils.BindAndRespond(controllers.GetCommentFormToken))
+			postGroup.POST("/:id/comments", middlewares.OptionalJWTMiddleware(), utils.BindAndRespondR(controllers.CreateComment))
+		}
+
+		// 后台管理
+		adminGroup := api.Group("admin", middlewares.JWTMiddleware())
+		{
+			// 评论审核，管理员和编辑可用
+			commentGroup := adminGroup.Group("comments", middlewares.RequireRole(models.RoleAdmin, models.RoleEditor))
+			{
+				commentGroup.GET("", utils.BindAndRespondR(controllers.ListCommentsForModeration))
+				commentGroup.DELETE("", utils.BindAndRespondR(controllers.DeleteComments))
+				commentGroup.PUT("/:id", utils.BindAndRespondR(controllers.UpdateComment))
+				commentGroup.PUT("/:id/approve", utils.BindAndRespond(controllers.ApproveComment))
+				commentGroup.PUT("/:id/reject", utils.BindAndRespond(controllers.RejectComment))
+			}
+
+			// 标签管理，管理员和编辑可用
+			tagGroup := adminGroup.Group("tags", middlewares.RequireRole(models.RoleAdmin, models.RoleEditor))
+			{
+				tagGroup.GET("", utils.BindAndRespond(controllers.ListTagsForAdmin))
+				tagGroup.POST("/merge", utils.BindAndRespondR(controllers.MergeTags))
+				tagGroup.DELETE("/unused", utils.BindAndRespond(controllers.DeleteUnusedTags))
+				tagGroup.PUT("/:id", utils.BindAndRespondR(controllers.UpdateTag))
+				tagGroup.DELETE("/:id", utils.BindAndRespond(controllers.DeleteTag))
+			}
+
+			// 搜索自定义词、同义词和索引重建，管理员和编辑可用，词典修改后立即生效
+			searchGroup := adminGroup.Group("search", middlewares.RequireRole(models.RoleAdmin, models.RoleEditor))
+			{
+				searchGroup.GET("/words", utils.BindAndRespond(controllers.ListSearchWords))
+				searchGroup.POST("/words", utils.BindAndRespondR(controllers.AddSearchWords))
+				searchGroup.DELETE("/words", utils.BindAndRespondR(controllers.DeleteSearchWords))
+				searchGroup.GET("/synonyms", utils.BindAndRespond(controllers.ListSynonymGroups))
+				searchGroup.POST("/synonyms", utils.BindAndRespondR(controllers.CreateSynonymGroup))
+				searchGroup.PUT("/synonyms/:id", utils.BindAndRespondR(controllers.UpdateSynonymGroup))
+				searchGroup.DELETE("/synonyms/:id", utils.BindAndRespond(controllers.DeleteSynonymGroup))
+				// 重建搜索索引，同一时间只运行一个任务
+				searchGroup.POST("/reindex", utils.BindAndRespondR(controllers.StartReindex))
+				searchGroup.GET("/reindex", utils.BindAndRespond(controllers.GetReindexStatus))
+				searchGroup.DELETE("/reindex", utils.BindAndRespond(controllers.CancelReindex))
+			}
 		}
 
 		thirdpartyGroup := api.Group("thirdparty")
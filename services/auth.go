@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token 无效或已过期")
+	ErrRefreshTokenReused  = errors.New("refresh token 已被使用，会话已注销")
+	ErrSessionNotFound     = errors.New("会话不存在")
+)
+
+func accessTokenTTL() time.Duration {
+	if ttl := config.GetConfig().GetDuration("jwt.accessTTL"); ttl > 0 {
+		return ttl
+	}
+	return 15 * time.Minute
+}
+
+func refreshTokenTTL() time.Duration {
+	if ttl := config.GetConfig().GetDuration("jwt.refreshTTL"); ttl > 0 {
+		return ttl
+	}
+	return 30 * 24 * time.Hour
+}
+
+// hashRefreshToken 数据库中只保存 refresh token 的 SHA-256
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateAccessToken 签发 access token，jti 用于单独吊销，sid 关联登录会话
+func generateAccessToken(user *models.User, sessionID string) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"sub":      user.ID,
+		"username": user.Name,
+		"role":     user.Role,
+		"jti":      uuid.New().String(),
+		"sid":      sessionID,
+		"iat":      now.Unix(),
+		"exp":      now.Add(accessTokenTTL()).Unix(),
+	}
+	return token.Sign(claims)
+}
+
+// issueRefreshToken 在会话中生成一个新的 refresh token
+func issueRefreshToken(tx *gorm.DB, session *models.Session) (string, error) {
+	raw, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	refresh := models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashRefreshToken(raw),
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return "", err
+	}
+
+	// 会话的有效期随最新的 refresh token 顺延
+	session.ExpiresAt = refresh.ExpiresAt
+	session.LastUsedAt = time.Now()
+	if err := tx.Save(session).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func newTokenPair(user *models.User, sessionID, refreshToken string) (*forms.TokenPair, error) {
+	accessToken, err := generateAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &forms.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL() / time.Second),
+	}, nil
+}
+
+// IssueTokenPair 登录成功后创建会话并签发 access/refresh token
+func IssueTokenPair(user *models.User, userAgent, ip string) (*forms.TokenPair, error) {
+	session := models.Session{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+
+	var refreshToken string
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		refreshToken, err = issueRefreshToken(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenPair(user, session.ID, refreshToken)
+}
+
+// RefreshTokenPair 使用 refresh token 换取新的令牌对，旧 refresh token 随即失效
+// 已轮换的 refresh token 再次出现时视为泄露，注销整个会话
+func RefreshTokenPair(raw, userAgent, ip string) (*forms.TokenPair, error) {
+	var (
+		user         models.User
+		session      models.Session
+		refreshToken string
+		reused       bool
+	)
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashRefreshToken(raw)).
+			First(&stored).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, "id = ?", stored.SessionID).Error; err != nil {
+			return err
+		}
+		if session.RevokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		now := time.Now()
+		if stored.UsedAt != nil {
+			// 提交吊销后再返回错误，不能让事务回滚
+			reused = true
+			return tx.Model(&session).Update("revoked_at", now).Error
+		}
+		if now.After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.Model(&stored).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&user, "id = ?", session.UserID).Error; err != nil {
+			return err
+		}
+		if !user.Active {
+			return ErrUserInactive
+		}
+
+		session.UserAgent = userAgent
+		session.IP = ip
+		refreshToken, err = issueRefreshToken(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return newTokenPair(&user, session.ID, refreshToken)
+}
+
+// RevokeSession 注销用户的某个会话，会话内的 refresh token 和 access token 全部失效
+func RevokeSession(userID, sessionID string) error {
+	result := db.GetDB().Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// Logout 吊销当前 access token 并注销其所在会话
+func Logout(userID, jti, sessionID string, expiresAt time.Time) error {
+	if err := token.Revoke(jti, expiresAt); err != nil {
+		return err
+	}
+	if sessionID == "" {
+		return nil
+	}
+	if err := RevokeSession(userID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ListActiveSessions 列出用户仍然有效的会话
+func ListActiveSessions(userID string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := db.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/services/spam"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCommentNotFound      = errors.New("评论不存在")
+	ErrCommentPostNotFound  = errors.New("文章不存在或不允许评论")
+	ErrCommentParentInvalid = errors.New("回复的评论不存在")
+	ErrCommentAuthorMissing = errors.New("匿名评论需要填写昵称和邮箱")
+	ErrCommentRejected      = errors.New("评论被拒绝")
+	ErrCommentRateLimited   = errors.New("评论过于频繁，请稍后再试")
+)
+
+// CommentInput 提交评论时的参数
+type CommentInput struct {
+	PostID        uint
+	ParentID      *uint
+	AuthorName    string
+	AuthorEmail   string
+	AuthorWebsite string
+	Content       string
+	IP            string
+	UserAgent     string
+	Referrer      string
+	Honeypot      string
+	FormToken     string
+}
+
+// commentAutoApproved 管理员、编辑和作者的评论无需审核
+func commentAutoApproved(viewer Viewer) bool {
+	switch viewer.Role {
+	case models.RoleAdmin, models.RoleEditor, models.RoleAuthor:
+		return viewer.UserID != ""
+	}
+	return false
+}
+
+// CreateComment 提交评论，只能评论已发布文章，只能回复同一文章下已通过的评论
+// 登录用户使用自己的用户名作为昵称；除管理员、编辑和作者外，评论入库前都要经过反垃圾检查
+func CreateComment(ctx context.Context, viewer Viewer, username string, input CommentInput) (*models.Comment, error) {
+	comment := models.Comment{
+		PostID:        input.PostID,
+		ParentID:      input.ParentID,
+		AuthorName:    strings.TrimSpace(input.AuthorName),
+		AuthorEmail:   strings.TrimSpace(input.AuthorEmail),
+		AuthorWebsite: strings.TrimSpace(input.AuthorWebsite),
+		Content:       strings.TrimSpace(input.Content),
+		Status:        models.CommentStatusPending,
+		IP:            input.IP,
+		UserAgent:     input.UserAgent,
+	}
+	if viewer.UserID != "" {
+		comment.UserID = viewer.UserID
+		comment.AuthorName = username
+	} else if comment.AuthorName == "" || comment.AuthorEmail == "" {
+		return nil, ErrCommentAuthorMissing
+	}
+
+	var post models.Post
+	err := db.GetDB().Scopes(PublishedPosts).Select("id", "slug").First(&post, input.PostID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCommentPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if commentAutoApproved(viewer) {
+		comment.Status = models.CommentStatusApproved
+	} else {
+		candidate := commentCandidate(&comment)
+		candidate.Referrer = input.Referrer
+		candidate.Permalink = PostURL(post)
+		candidate.Honeypot = input.Honeypot
+		candidate.FormToken = input.FormToken
+
+		result := spam.Check(ctx, candidate)
+		switch result.Verdict {
+		case spam.VerdictReject:
+			return nil, ErrCommentRejected
+		case spam.VerdictThrottle:
+			return nil, ErrCommentRateLimited
+		case spam.VerdictSpam:
+			comment.Status = models.CommentStatusSpam
+		}
+		if result.Verdict != spam.VerdictHam {
+			comment.SpamReason = result.Checker + ": " + result.Reason
+		}
+	}
+
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if input.ParentID != nil {
+			var parent models.Comment
+			err := tx.Where("id = ? AND post_id = ? AND status = ?", *input.ParentID, input.PostID, models.CommentStatusApproved).
+				First(&parent).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCommentParentInvalid
+			}
+			if err != nil {
+				return err
+			}
+			comment.RootID = parent.RootID
+		}
+
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		// 顶层评论的楼层就是它自己
+		if comment.RootID == 0 {
+			comment.RootID = comment.ID
+			return tx.Model(&comment).Update("root_id", comment.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func commentCandidate(comment *models.Comment) *spam.Candidate {
+	return &spam.Candidate{
+		PostID:        comment.PostID,
+		AuthorName:    comment.AuthorName,
+		AuthorEmail:   comment.AuthorEmail,
+		AuthorWebsite: comment.AuthorWebsite,
+		Content:       comment.Content,
+		IP:            comment.IP,
+		UserAgent:     comment.UserAgent,
+	}
+}
+
+// ListApprovedComments 按顶层评论分页获取文章下已通过的评论，同时返回这些楼层内的全部回复
+// 顶层评论已删除或未通过、但楼层内有已通过的回复时也会返回，未通过的评论由调用方作为占位展示，不能公开内容
+func ListApprovedComments(postID uint, page, pageSize int) (roots []models.Comment, replies []models.Comment, total int64, err error) {
+	base := db.GetDB().Model(&models.Comment{}).
+		Where("post_id = ? AND parent_id IS NULL", postID).
+		Where("status = ? OR EXISTS (SELECT 1 FROM comments r WHERE r.root_id = comments.id AND r.parent_id IS NOT NULL AND r.status = ? AND r.deleted_at IS NULL)",
+			models.CommentStatusApproved, models.CommentStatusApproved)
+	if err = base.Count(&total).Error; err != nil {
+		return
+	}
+
+	if err = base.Order("created_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&roots).Error; err != nil || len(roots) == 0 {
+		return
+	}
+
+	rootIDs := make([]uint, len(roots))
+	for i, r := range roots {
+		rootIDs[i] = r.ID
+	}
+	err = db.GetDB().
+		Where("root_id IN ? AND parent_id IS NOT NULL", rootIDs).
+		Order("created_at ASC").
+		Find(&replies).Error
+	return
+}
+
+// ListCommentsForModeration 审核列表，默认按时间倒序列出所有状态
+func ListCommentsForModeration(status string, postID uint, page, pageSize int) ([]models.Comment, int64, error) {
+	tx := db.GetDB().Model(&models.Comment{})
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if postID != 0 {
+		tx = tx.Where("post_id = ?", postID)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var comments []models.Comment
+	err := tx.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&comments).Error
+	return comments, total, err
+}
+
+// GetComment 根据 ID 获取评论
+func GetComment(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	err := db.GetDB().First(&comment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// SetCommentStatus 修改评论状态（通过、拒绝），审核结论同时反馈给反垃圾分类器
+func SetCommentStatus(id uint, status string) (*models.Comment, error) {
+	comment, err := GetComment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *bool
+	if comment.SpamTrainedAs != "" {
+		wasSpam := comment.SpamTrainedAs == models.CommentStatusSpam
+		previous = &wasSpam
+	}
+	isSpam := status == models.CommentStatusSpam
+	trainedAs := "ham"
+	if isSpam {
+		trainedAs = models.CommentStatusSpam
+	}
+
+	if err := db.GetDB().Model(comment).Updates(map[string]any{
+		"status":          status,
+		"spam_trained_as": trainedAs,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	// 同一结论只学习一次，改判时由 Learn 撤销上一次的结论
+	if previous == nil || *previous != isSpam {
+		candidate := commentCandidate(comment)
+		// 远程服务可能较慢，不阻塞审核操作
+		go spam.Learn(context.Background(), candidate, isSpam, previous)
+	}
+	return comment, nil
+}
+
+// UpdateComment 管理员修改评论内容，昵称和网址为空时保持不变
+func UpdateComment(id uint, authorName, authorWebsite, content string) (*models.Comment, error) {
+	comment, err := GetComment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{"content": strings.TrimSpace(content)}
+	if name := strings.TrimSpace(authorName); name != "" {
+		updates["author_name"] = name
+	}
+	if website := strings.TrimSpace(authorWebsite); website != "" {
+		updates["author_website"] = website
+	}
+	if err := db.GetDB().Model(comment).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComments 批量把评论标记为已删除，保留记录以免回复失去上下文，返回实际删除的条数
+func DeleteComments(ids []uint) (int64, error) {
+	result := db.GetDB().Model(&models.Comment{}).
+		Where("id IN ? AND status <> ?", ids, models.CommentStatusDeleted).
+		Update("status", models.CommentStatusDeleted)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/utils"
+)
+
+// Feed 与输出格式无关的 feed 内容
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // 站点（或标签页）地址
+	FeedURL     string // feed 自身地址
+	Author      string
+	Updated     time.Time
+	Items       []FeedItem
+}
+
+type FeedItem struct {
+	ID        string
+	Title     string
+	Link      string
+	Content   string // 全文模式下为正文，否则为空
+	Summary   string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// LatestFeedPosts 获取 feed 使用的最新已发布文章，tagName 不为空时只取该标签下的文章
+func LatestFeedPosts(tagName string, limit int) ([]models.Post, error) {
+	query := db.GetDB().Scopes(PublishedPosts)
+
+	if tagName != "" {
+		var tag models.Tag
+		if err := db.GetDB().Where("name_key = ?", utils.TagNameKey(tagName)).First(&tag).Error; err != nil {
+			return nil, err
+		}
+		query = query.Scopes(PostsWithTags([]string{tag.Name}, TagMatchAny))
+	}
+
+	var posts []models.Post
+	err := query.Order("adjust_time DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// BuildFeed 根据文章列表和配置生成 feed 内容
+func BuildFeed(posts []models.Post, tagName, feedURL string) (*Feed, error) {
+	cfg := config.GetConfig()
+	fullContent := cfg.GetBool("feed.fullContent")
+	summaryLength := cfg.GetInt("feed.summaryLength")
+	if summaryLength <= 0 {
+		summaryLength = 200
+	}
+
+	feed := &Feed{
+		Title:       cfg.GetString("site.title"),
+		Description: cfg.GetString("site.description"),
+		Link:        SiteURL(),
+		FeedURL:     feedURL,
+		Author:      cfg.GetString("site.author"),
+	}
+	if tagName != "" {
+		feed.Title += " - " + tagName
+		feed.Link = TagURL(tagName)
+	}
+
+	postIDs := make([]uint, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	tagNames, err := GetTagNamesByPostIDs(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		item := FeedItem{
+			ID:        PostURL(post),
+			Title:     post.Title,
+			Link:      PostURL(post),
+			Summary:   GenerateSummary(post.Content, "", summaryLength, 0),
+			Tags:      tagNames[post.ID],
+			Published: post.AdjustTime,
+			Updated:   post.UpdatedAt,
+		}
+		if fullContent {
+			item.Content = post.Content
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	feed.Updated = FeedLastModified(posts)
+
+	return feed, nil
+}
+
+// --- RSS 2.0 ---
+
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXMLNS string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS 生成 RSS 2.0 文档
+func (f *Feed) RSS() ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		AtomLink:    rssLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		description := item.Summary
+		if item.Content != "" {
+			description = item.Content
+		}
+		channel.Items = append(channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: description,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.ID},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Categories:  item.Tags,
+		})
+	}
+
+	return marshalXML(rss{Version: "2.0", AtomXMLNS: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+// --- Atom 1.0 ---
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomAuthor `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// Atom 生成 Atom 1.0 文档
+func (f *Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.FeedURL,
+		Updated:  f.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	if f.Author != "" {
+		feed.Author = &atomAuthor{Name: f.Author}
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+			Summary:   &atomText{Type: "text", Body: item.Summary},
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "text", Body: item.Content}
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
+
+// --- JSON Feed 1.1 ---
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	Summary       string   `json:"summary,omitempty"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// JSONFeed 生成 JSON Feed 1.1 文档
+func (f *Feed) JSONFeed() ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if f.Author != "" {
+		feed.Authors = []jsonFeedAuthor{{Name: f.Author}}
+	}
+
+	for _, item := range f.Items {
+		content := item.Summary
+		if item.Content != "" {
+			content = item.Content
+		}
+		feed.Items = append(feed.Items, jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   content,
+			Summary:       item.Summary,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Tags,
+		})
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+// FeedLastModified 返回 feed 中文章最近的发布或修改时间，用于 Last-Modified
+func FeedLastModified(posts []models.Post) time.Time {
+	var latest time.Time
+	for _, post := range posts {
+		for _, t := range []time.Time{post.AdjustTime, post.UpdatedAt} {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
+
+// FeedETag 根据 feed 类型、标签、文章的最新发布时间和修改时间、文章数和全文配置生成 ETag
+// 只修改标题或正文时 adjust_time 不变，需要 updated_at 才能让订阅端拿到新内容
+func FeedETag(kind, tagName string, posts []models.Post) string {
+	var published, updated time.Time
+	for _, post := range posts {
+		if post.AdjustTime.After(published) {
+			published = post.AdjustTime
+		}
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	fullContent := config.GetConfig().GetBool("feed.fullContent")
+	raw := fmt.Sprintf("%s|%s|%d|%d|%d|%t", kind, tagName, published.UnixNano(), updated.UnixNano(), len(posts), fullContent)
+	sum := sha1.Sum([]byte(raw))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+func marshalXML(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/services/token"
+	"blog-server/utils"
+
+	"gorm.io/gorm"
+)
+
+var ErrPublishAtRequired = errors.New("定时发布必须指定 publishAt")
+
+// ResolvePostStatus 校验并规范化文章状态
+// 空状态视为直接发布；定时发布时间已过的文章直接转为已发布
+func ResolvePostStatus(status string, publishAt *time.Time) (string, *time.Time, error) {
+	if status == "" {
+		status = models.PostStatusPublished
+	}
+
+	if status != models.PostStatusScheduled {
+		return status, publishAt, nil
+	}
+
+	if publishAt == nil {
+		return "", nil, ErrPublishAtRequired
+	}
+	if !publishAt.After(time.Now()) {
+		return models.PostStatusPublished, publishAt, nil
+	}
+	return status, publishAt, nil
+}
+
+// ApplyPostStatusUpdate 修改文章时更新状态，status 为空时保留原状态和定时发布时间，
+// 避免只修改内容的请求把草稿或定时文章直接发布
+func ApplyPostStatusUpdate(post *models.Post, status string, publishAt *time.Time) error {
+	if status == "" {
+		return nil
+	}
+	status, publishAt, err := ResolvePostStatus(status, publishAt)
+	if err != nil {
+		return err
+	}
+	post.Status = status
+	post.PublishAt = publishAt
+	return nil
+}
+
+// Viewer 当前请求的用户身份，匿名访问时为零值
+type Viewer struct {
+	UserID string
+	Role   string
+}
+
+// CanManageAllPosts 管理员和编辑可以管理所有文章
+func (v Viewer) CanManageAllPosts() bool {
+	return v.Role == models.RoleAdmin || v.Role == models.RoleEditor
+}
+
+// CanManagePost 判断用户能否编辑/删除指定文章，作者只能管理自己的文章
+func (v Viewer) CanManagePost(post models.Post) bool {
+	if v.CanManageAllPosts() {
+		return true
+	}
+	return v.Role == models.RoleAuthor && v.UserID != "" && post.AuthorID == v.UserID
+}
+
+// PostVisibility 返回文章可见范围的查询条件
+// 匿名用户和读者只能看到已发布文章，作者还能看到自己的未发布文章，编辑和管理员可以看到全部
+// status 不为空时只返回该状态的文章
+func PostVisibility(viewer Viewer, status string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		switch {
+		case viewer.CanManageAllPosts():
+			if status != "" {
+				tx = tx.Where("status = ?", status)
+			}
+			return tx
+		case viewer.Role == models.RoleAuthor && viewer.UserID != "":
+			if status != "" {
+				tx = tx.Where("status = ?", status)
+			}
+			return tx.Where("status = ? OR author_id = ?", models.PostStatusPublished, viewer.UserID)
+		default:
+			return tx.Where("status = ?", models.PostStatusPublished)
+		}
+	}
+}
+
+// PublishedPosts 只返回已发布文章，用于所有公开接口
+func PublishedPosts(tx *gorm.DB) *gorm.DB {
+	return PostVisibility(Viewer{}, "")(tx)
+}
+
+// PublishDuePosts 把到期的定时文章转为已发布，返回处理的文章数
+func PublishDuePosts(now time.Time) (int64, error) {
+	result := db.GetDB().Model(&models.Post{}).
+		Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+		Updates(map[string]any{
+			"status":      models.PostStatusPublished,
+			"adjust_time": gorm.Expr("publish_at"),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// StartPostScheduler 启动后台定时任务：发布到期的定时文章，清理过期的 token 吊销记录
+func StartPostScheduler(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			if n, err := PublishDuePosts(now); err != nil {
+				utils.Log("定时发布失败: ", err)
+			} else if n > 0 {
+				utils.Log("定时发布文章数: ", n)
+			}
+
+			if n, err := token.PurgeExpired(now); err != nil {
+				utils.Log("清理过期吊销记录失败: ", err)
+			} else if n > 0 {
+				utils.Log("清理过期吊销记录数: ", n)
+			}
+		}
+	}()
+}
+
+// postStatsVersion 文章统计算法的版本，修改 utils.AnalyzeMarkdown 的结果时加一，启动时会重新计算所有文章
+const postStatsVersion = 1
+
+// ApplyPostStats 根据正文重新计算文章目录、字数和阅读时间
+func ApplyPostStats(post *models.Post) {
+	stats := utils.AnalyzeMarkdown([]byte(post.Content))
+	post.TOC = stats.TOC
+	post.WordCount = stats.WordCount
+	post.ReadingTime = stats.ReadingTime
+	post.StatsVersion = postStatsVersion
+}
+
+// BackfillPostStats 为统计信息缺失或过期的文章计算目录和字数
+// 按 stats_version 判断，字数为 0 的文章（如只有代码或图片）计算一次后不会再被选中
+func BackfillPostStats() error {
+	var posts []models.Post
+	if err := db.GetDB().Unscoped().
+		Select("id", "content").
+		Where("stats_version < ?", postStatsVersion).
+		Order("id ASC").
+		Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		ApplyPostStats(&post)
+		if err := db.GetDB().Unscoped().Model(&post).UpdateColumns(map[string]any{
+			"toc":           post.TOC,
+			"word_count":    post.WordCount,
+			"reading_time":  post.ReadingTime,
+			"stats_version": post.StatsVersion,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to backfill stats for post ID %d: %w", post.ID, err)
+		}
+	}
+
+	if len(posts) > 0 {
+		utils.Log("Post stats backfilled: ", len(posts))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blog-server/models"
+)
+
+func TestApplyPostStatusUpdate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name          string
+		post          models.Post
+		status        string
+		publishAt     *time.Time
+		wantStatus    string
+		wantPublishAt *time.Time
+	}{
+		{name: "draft without status stays draft", post: models.Post{Status: models.PostStatusDraft}, wantStatus: models.PostStatusDraft},
+		{name: "scheduled without status keeps publishAt", post: models.Post{Status: models.PostStatusScheduled, PublishAt: &future}, wantStatus: models.PostStatusScheduled, wantPublishAt: &future},
+		{name: "publish draft", post: models.Post{Status: models.PostStatusDraft}, status: models.PostStatusPublished, wantStatus: models.PostStatusPublished},
+		{name: "schedule in the past publishes", post: models.Post{Status: models.PostStatusDraft}, status: models.PostStatusScheduled, publishAt: &past, wantStatus: models.PostStatusPublished, wantPublishAt: &past},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := tt.post
+			if err := ApplyPostStatusUpdate(&post, tt.status, tt.publishAt); err != nil {
+				t.Fatalf("ApplyPostStatusUpdate() error = %v", err)
+			}
+			if post.Status != tt.wantStatus || post.PublishAt != tt.wantPublishAt {
+				t.Errorf("got status %q publishAt %v, want %q %v", post.Status, post.PublishAt, tt.wantStatus, tt.wantPublishAt)
+			}
+		})
+	}
+
+	post := models.Post{Status: models.PostStatusDraft}
+	if err := ApplyPostStatusUpdate(&post, models.PostStatusScheduled, nil); !errors.Is(err, ErrPublishAtRequired) {
+		t.Errorf("scheduled without publishAt: error = %v, want ErrPublishAtRequired", err)
+	}
+	if post.Status != models.PostStatusDraft {
+		t.Errorf("status changed to %q after error", post.Status)
+	}
+}
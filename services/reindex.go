@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReindexRunning    = errors.New("索引正在重建，请稍后再试")
+	ErrReindexNotRunning = errors.New("没有正在运行的重建任务")
+)
+
+const (
+	defaultReindexBatchSize = 100
+	maxReindexErrors        = 100 // 状态中最多保留的失败文章数
+)
+
+// reindexState 当前或最近一次重建任务，进程内同一时间只运行一个
+var reindexState struct {
+	sync.Mutex
+	status forms.ReindexStatus
+	cancel context.CancelFunc
+}
+
+// StartReindex 在后台分批重新生成所有文章的 tokens，立即返回任务状态
+// 已有任务在运行时返回 ErrReindexRunning；batchSize <= 0 时使用 search.reindex.batchSize
+func StartReindex(batchSize int) (forms.ReindexStatus, error) {
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	if reindexState.status.State == forms.ReindexStateRunning {
+		return reindexState.status, ErrReindexRunning
+	}
+	return startReindexLocked(batchSize)
+}
+
+// QueueReindex 在后台重建索引，已有任务在运行时排队，在其结束后用默认批大小再运行一次
+// 修改词典后调用，保证正在运行的任务已处理过的文章也能用上新的词典；排队的任务随当前任务一起取消
+func QueueReindex() (forms.ReindexStatus, error) {
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	if reindexState.status.State == forms.ReindexStateRunning {
+		reindexState.status.Queued = true
+		return reindexState.status, nil
+	}
+	return startReindexLocked(0)
+}
+
+// startReindexLocked 启动重建任务，调用方需持有 reindexState 的锁
+func startReindexLocked(batchSize int) (forms.ReindexStatus, error) {
+	if batchSize <= 0 {
+		batchSize = config.GetConfig().GetInt("search.reindex.batchSize")
+	}
+	if batchSize <= 0 {
+		batchSize = defaultReindexBatchSize
+	}
+
+	var total int64
+	if err := db.GetDB().Model(&models.Post{}).Count(&total).Error; err != nil {
+		return forms.ReindexStatus{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reindexState.cancel = cancel
+	reindexState.status = forms.ReindexStatus{
+		State:     forms.ReindexStateRunning,
+		BatchSize: batchSize,
+		Total:     total,
+		Errors:    []forms.ReindexError{},
+		StartedAt: time.Now(),
+	}
+	go runReindex(ctx, batchSize)
+	return reindexState.status, nil
+}
+
+// GetReindexStatus 返回当前或最近一次重建任务的状态，从未运行过时 State 为 idle
+func GetReindexStatus() forms.ReindexStatus {
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	status := reindexState.status
+	if status.State == "" {
+		status.State = forms.ReindexStateIdle
+	}
+	status.Errors = append([]forms.ReindexError{}, status.Errors...)
+	return status
+}
+
+// CancelReindex 取消正在运行的重建任务和排在其后的任务，正在处理的文章完成后停止，已更新的文章不会回滚
+func CancelReindex() (forms.ReindexStatus, error) {
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	if reindexState.status.State != forms.ReindexStateRunning {
+		return reindexState.status, ErrReindexNotRunning
+	}
+	reindexState.cancel()
+	return reindexState.status, nil
+}
+
+// runReindex 按 ID 分批处理文章，单篇失败只记录，不中断任务
+func runReindex(ctx context.Context, batchSize int) {
+	var lastID uint
+	state, message := forms.ReindexStateCompleted, ""
+
+	for {
+		if ctx.Err() != nil {
+			state = forms.ReindexStateCancelled
+			break
+		}
+
+		var ids []uint
+		err := db.GetDB().WithContext(ctx).Model(&models.Post{}).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if ctx.Err() != nil {
+			state = forms.ReindexStateCancelled
+			break
+		}
+		if err != nil {
+			state, message = forms.ReindexStateFailed, err.Error()
+			break
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				break
+			}
+			recordReindexProgress(id, reindexPost(id))
+			lastID = id
+		}
+	}
+
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	reindexState.cancel()
+	reindexState.cancel = nil
+	now := time.Now()
+	queued := reindexState.status.Queued && state != forms.ReindexStateCancelled
+	reindexState.status.State = state
+	reindexState.status.Message = message
+	reindexState.status.Queued = false
+	reindexState.status.FinishedAt = &now
+
+	s := reindexState.status
+	if state == forms.ReindexStateFailed {
+		log.Printf("search reindex failed after %d/%d posts: %s", s.Processed, s.Total, message)
+	} else {
+		utils.Log("Search reindex " + state + ".")
+	}
+
+	if queued {
+		if _, err := startReindexLocked(0); err != nil {
+			log.Printf("failed to start queued search reindex: %v", err)
+		}
+	}
+}
+
+// reindexPost 在事务中锁定文章后重新读取并生成 tokens，避免用过期的内容覆盖同时保存的文章的索引
+// 文章在此期间被删除时跳过
+func reindexPost(id uint) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return UpdatePostTokens(tx, &post)
+	})
+}
+
+func recordReindexProgress(postID uint, err error) {
+	if err != nil {
+		log.Printf("failed to reindex post ID %d: %v", postID, err)
+	}
+
+	reindexState.Lock()
+	defer reindexState.Unlock()
+	status := &reindexState.status
+	status.Processed++
+	if err != nil {
+		status.Failed++
+		if len(status.Errors) < maxReindexErrors {
+			status.Errors = append(status.Errors, forms.ReindexError{PostID: postID, Error: err.Error()})
+		}
+	}
+}
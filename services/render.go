@@ -0,0 +1,27 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"blog-server/models"
+	"blog-server/utils"
+)
+
+// 渲染结果按文章 ID + 更新时间缓存，文章更新后 key 随之变化，旧结果由 LRU 淘汰
+var renderedPosts, _ = utils.NewCache[string](256, 24*time.Hour)
+
+// RenderPostHTML 把文章正文渲染为过滤后的 HTML
+func RenderPostHTML(post models.Post) (string, error) {
+	key := fmt.Sprintf("%d:%d", post.ID, post.UpdatedAt.UnixNano())
+	if html, ok := renderedPosts.Get(key); ok {
+		return html, nil
+	}
+
+	html, err := utils.RenderMarkdown([]byte(post.Content))
+	if err != nil {
+		return "", err
+	}
+	renderedPosts.Set(key, html)
+	return html, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"blog-server/db"
+	"blog-server/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreatePostRevision 把文章当前内容保存为一个新版本，标签取自 post_tags，需先调用 SetPostTags
+// tx 必须是事务，文章行会被锁到事务结束
+func CreatePostRevision(tx *gorm.DB, post *models.Post, editor string) (*models.PostRevision, error) {
+	tagIDs, err := GetPostTagIDs(tx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 锁住文章，避免同时保存的请求算出相同的版本号
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Post{}, post.ID).Error; err != nil {
+		return nil, err
+	}
+
+	var latest int
+	if err := tx.Model(&models.PostRevision{}).
+		Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	revision := models.PostRevision{
+		PostID:  post.ID,
+		Version: latest + 1,
+		Title:   post.Title,
+		Content: post.Content,
+		ImgUrl:  post.ImgUrl,
+		TagIDs:  tagIDs,
+		Status:  post.Status,
+		Editor:  editor,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// ListPostRevisions 按版本倒序列出文章的所有版本（不含正文）
+func ListPostRevisions(postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := db.GetDB().
+		Select("id", "post_id", "version", "title", "img_url", "tag_ids", "status", "editor", "created_at").
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// GetPostRevision 获取文章的指定版本
+func GetPostRevision(postID uint, version int) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	if err := db.GetDB().Where("post_id = ? AND version = ?", postID, version).First(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// RestorePostRevision 把文章恢复到指定版本，恢复结果作为一个新版本保存
+func RestorePostRevision(postID uint, version int, editor string) (*models.Post, error) {
+	var post models.Post
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&post, postID).Error; err != nil {
+			return err
+		}
+
+		var revision models.PostRevision
+		if err := tx.Where("post_id = ? AND version = ?", postID, version).First(&revision).Error; err != nil {
+			return err
+		}
+
+		post.Title = revision.Title
+		post.Content = revision.Content
+		post.ImgUrl = revision.ImgUrl
+		ApplyPostStats(&post)
+		if err := tx.Save(&post).Error; err != nil {
+			return err
+		}
+		if err := SetPostTags(tx, post.ID, revision.TagIDs); err != nil {
+			return err
+		}
+
+		if _, err := CreatePostRevision(tx, &post, editor); err != nil {
+			return err
+		}
+		// 内容变化后重新生成 tokens
+		return UpdatePostTokens(tx, &post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
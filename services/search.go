@@ -1,68 +1,86 @@
 package services
 
 import (
+	"blog-server/config"
 	"blog-server/db"
 	"blog-server/models"
+	"blog-server/services/tokenizer"
 	"blog-server/utils"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 
-	"github.com/huichen/sego"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var Segmenter sego.Segmenter
-
-// 初始化分词器
-func init() {
-	Segmenter.LoadDictionary("data/dictionary.txt") // 放置你的 sego 词典
-	utils.Log("Sego segmenter initialized.")
-}
+var (
+	tokenizerOnce   sync.Once
+	searchTokenizer *tokenizer.Pipeline
+	// 后台维护的自定义词，见 ReloadSearchDictionary
+	searchUserWords *tokenizer.UserWords
+	// 当前分词方式对应的索引版本，写入 posts.tokens_version
+	searchIndexVersion string
+)
 
-// UpdateAllExistingPostsTokens 对数据库里所有文章生成 tokens
-func UpdateAllExistingPostsTokens(batchSize int) error {
-	var lastID uint = 0
-
-	for {
-		var posts []models.Post
-		err := db.GetDB().
-			Where("id > ?", lastID).
-			Order("id ASC").
-			Limit(batchSize).
-			Find(&posts).Error
+// searchIndexRevision 生成 tokens 的规则（分词流水线、字段权重等）变化时加一，启动时会重建所有文章的索引
+const searchIndexRevision = 1
+
+// SearchTokenizer 返回生成索引和解析查询共用的分词器，按 search.tokenizer 配置创建
+// sego 词典不存在时退回不需要词典的二元切分，并记录警告；自定义词在词典分词之前切出
+func SearchTokenizer() tokenizer.Tokenizer {
+	tokenizerOnce.Do(func() {
+		cfg := config.GetConfig()
+		tc := tokenizer.Config{
+			Backend:    cfg.GetString("search.tokenizer.backend"),
+			Dictionary: cfg.GetString("search.tokenizer.dictionary"),
+			Stem:       cfg.GetBool("search.tokenizer.stem"),
+			StopWords:  cfg.GetBool("search.tokenizer.stopWords"),
+		}
+		backend := tc.Backend
+		if backend == "" {
+			backend = tokenizer.BackendSego
+		}
+		p, err := tokenizer.New(tc)
 		if err != nil {
-			return err
+			log.Printf("failed to create %q tokenizer, falling back to bigram: %v", tc.Backend, err)
+			p = &tokenizer.Pipeline{Backend: tokenizer.Bigram{}, Stem: tc.Stem, StopWords: tc.StopWords}
+			backend = tokenizer.BackendBigram
 		}
+		searchIndexVersion = fmt.Sprintf("%d:%s:stem=%t:stopwords=%t", searchIndexRevision, backend, tc.Stem, tc.StopWords)
+		searchUserWords = tokenizer.NewUserWords(p.Backend)
+		p.Backend = searchUserWords
+		searchTokenizer = p
+		utils.Log("Search tokenizer initialized.")
+	})
+	return searchTokenizer
+}
 
-		if len(posts) == 0 {
-			break // 已处理完所有文章
-		}
+// SearchIndexVersion 返回当前分词方式对应的索引版本，分词后端、词干提取、停用词配置或 searchIndexRevision 变化时改变
+func SearchIndexVersion() string {
+	SearchTokenizer()
+	return searchIndexVersion
+}
 
-		for _, post := range posts {
-			if err := UpdatePostTokens(&post); err != nil {
-				log.Printf("failed to update tokens for post ID %d: %v", post.ID, err)
-			}
-			lastID = post.ID
-		}
+// ReindexStalePosts 有文章的索引不是用当前分词方式生成的时候，在后台重建全部索引
+// 启动时调用，修改 search.tokenizer 配置或升级分词规则后无需手动触发重建
+func ReindexStalePosts() error {
+	var stale int64
+	if err := db.GetDB().Model(&models.Post{}).Where("tokens_version <> ?", SearchIndexVersion()).Count(&stale).Error; err != nil {
+		return err
 	}
-
-	utils.Log("All existing posts tokens updated successfully.")
-	return nil
+	if stale == 0 {
+		return nil
+	}
+	utils.Log("Search index is stale, reindexing posts: ", stale)
+	_, err := StartReindex(0)
+	return err
 }
 
-// 分词函数
+// SegmentText 用 SearchTokenizer 分词，生成 tokens 和解析搜索查询都必须经过这里
 func SegmentText(text string) []string {
-	segments := Segmenter.Segment([]byte(text))
-	words := sego.SegmentsToSlice(segments, true)
-	var result []string
-	for _, w := range words {
-		if len(w) <= 1 {
-			continue
-		}
-		result = append(result, w)
-	}
-	return result
+	return SearchTokenizer().Tokenize(text)
 }
 
 // 转换为 PostgreSQL tsvector 可用字符串
@@ -70,39 +88,80 @@ func ToTSVector(words []string) string {
 	return strings.Join(words, " ")
 }
 
-// 更新单篇文章的 tokens
-func UpdatePostTokens(post *models.Post) error {
-	titleWords := SegmentText(post.Title)
-	contentWords := SegmentText(post.Content)
+// IndexError 生成文章搜索索引失败，Stage 为失败的步骤
+type IndexError struct {
+	PostID uint
+	Stage  string
+	Err    error
+}
 
-	titleVector := ToTSVector(titleWords)
-	contentVector := ToTSVector(contentWords)
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("文章 %d 生成搜索索引失败（%s）: %v", e.PostID, e.Stage, e.Err)
+}
 
-	// setweight 给 title 权重 A，content 权重 B
-	tsvectorSQL := fmt.Sprintf(
-		"setweight(to_tsvector('simple', '%s'), 'A') || setweight(to_tsvector('simple', '%s'), 'B')",
-		titleVector,
-		contentVector,
-	)
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
 
-	return db.GetDB().Model(post).Update("tokens", gorm.Expr(tsvectorSQL)).Error
+// postTokensExpr 返回 tokens 的 SQL 表达式，title 权重 A，content 权重 B，标签权重 C
+// 分词结果全部作为参数传入，不拼接进 SQL
+func postTokensExpr(title, content, tags string) clause.Expr {
+	return gorm.Expr(
+		"setweight(to_tsvector('simple', ?), 'A') || setweight(to_tsvector('simple', ?), 'B') || setweight(to_tsvector('simple', ?), 'C')",
+		title, content, tags,
+	)
 }
 
-// 批量更新所有文章 tokens
-func UpdateAllPostTokens() error {
-	var posts []models.Post
-	if err := db.GetDB().Find(&posts).Error; err != nil {
-		return err
+// UpdatePostTokens 更新单篇文章的 tokens，tx 可以是正在保存文章的事务，失败时返回 *IndexError
+func UpdatePostTokens(tx *gorm.DB, post *models.Post) error {
+	tagNames, err := tagNamesByPostIDs(tx, []uint{post.ID})
+	if err != nil {
+		return &IndexError{PostID: post.ID, Stage: "读取标签", Err: err}
 	}
 
-	for _, post := range posts {
-		if err := UpdatePostTokens(&post); err != nil {
-			return err
-		}
+	expr := postTokensExpr(
+		ToTSVector(SegmentText(post.Title)),
+		ToTSVector(SegmentText(post.Content)),
+		ToTSVector(SegmentText(strings.Join(tagNames[post.ID], " "))),
+	)
+	// 只更新 tokens，不改变 updated_at
+	if err := tx.Model(post).UpdateColumns(map[string]any{
+		"tokens":         expr,
+		"tokens_version": SearchIndexVersion(),
+	}).Error; err != nil {
+		return &IndexError{PostID: post.ID, Stage: "写入 tokens", Err: err}
 	}
 	return nil
 }
 
+// SearchHighlightOptions 读取 search.highlight 配置，未配置的项使用 utils.DefaultHighlightOptions
+func SearchHighlightOptions() utils.HighlightOptions {
+	cfg := config.GetConfig()
+	opts := utils.DefaultHighlightOptions
+	// 搜索词是词干，原文中的词也要提取词干后比较
+	SearchTokenizer()
+	opts.Normalize = searchTokenizer.Normalize
+	if cfg.IsSet("search.highlight.startSel") {
+		opts.StartSel = cfg.GetString("search.highlight.startSel")
+	}
+	if cfg.IsSet("search.highlight.stopSel") {
+		opts.StopSel = cfg.GetString("search.highlight.stopSel")
+	}
+	if n := cfg.GetInt("search.highlight.maxLength"); n > 0 {
+		opts.MaxLength = n
+	}
+	if n := cfg.GetInt("search.highlight.maxFragments"); n > 0 {
+		opts.MaxFragments = n
+	}
+	if cfg.IsSet("search.highlight.fragmentDelimiter") {
+		opts.FragmentDelimiter = cfg.GetString("search.highlight.fragmentDelimiter")
+	}
+	if cfg.IsSet("search.highlight.escapeHTML") {
+		opts.EscapeHTML = cfg.GetBool("search.highlight.escapeHTML")
+	}
+	return opts
+}
+
 // GenerateSummary 生成内容摘要
 // content: 原文
 // keyword: 搜索关键词，可以为空
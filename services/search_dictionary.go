@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/services/searchquery"
+	"blog-server/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSearchWordInvalid    = errors.New("自定义词只能包含文字和数字，不能有空格和标点")
+	ErrSynonymGroupNotFound = errors.New("同义词组不存在")
+	ErrSynonymGroupTooSmall = errors.New("同义词组至少需要两个不同的词")
+)
+
+// searchDictionaryMu 保证重新加载时读取数据库和替换词表是一个整体，后开始的加载总是最后生效
+var searchDictionaryMu sync.Mutex
+
+var searchSynonyms struct {
+	sync.RWMutex
+	m map[string][][]string // 分词后以空格连接的词 -> 同义词的分词结果
+}
+
+// normalizeDictionaryWords 去掉首尾空白、转为小写并去重，保持原有顺序
+func normalizeDictionaryWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		result = append(result, word)
+	}
+	return result
+}
+
+// synonymKey 返回词在查询中的分词结果，与 searchquery 传给 Expander 的词一致
+func synonymKey(word string) []string {
+	var result []string
+	for _, w := range SegmentText(word) {
+		if w = searchquery.Lexeme(w); w != "" {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// ListSearchWords 返回所有自定义词
+func ListSearchWords() ([]models.SearchWord, error) {
+	var words []models.SearchWord
+	err := db.GetDB().Order("word ASC").Find(&words).Error
+	return words, err
+}
+
+// AddSearchWords 添加自定义词，已存在的词会被忽略，添加后立即对新的分词生效
+// 自定义词会原样写入索引，而查询时会去掉标点和空格，所以只允许文字和数字，如 k8s、云原生
+func AddSearchWords(words []string) ([]models.SearchWord, error) {
+	words = normalizeDictionaryWords(words)
+	rows := make([]models.SearchWord, 0, len(words))
+	for _, word := range words {
+		if searchquery.Lexeme(word) != word {
+			return nil, ErrSearchWordInvalid
+		}
+		rows = append(rows, models.SearchWord{Word: word})
+	}
+	if len(rows) == 0 {
+		return nil, ErrSearchWordInvalid
+	}
+
+	err := db.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := ReloadSearchDictionary(); err != nil {
+		return nil, err
+	}
+	return ListSearchWords()
+}
+
+// DeleteSearchWords 删除自定义词，返回删除的数量
+func DeleteSearchWords(ids []uint) (int64, error) {
+	result := db.GetDB().Delete(&models.SearchWord{}, ids)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		if err := ReloadSearchDictionary(); err != nil {
+			return 0, err
+		}
+	}
+	return result.RowsAffected, nil
+}
+
+// ListSynonymGroups 返回所有同义词组
+func ListSynonymGroups() ([]models.SynonymGroup, error) {
+	var groups []models.SynonymGroup
+	err := db.GetDB().Order("id ASC").Find(&groups).Error
+	return groups, err
+}
+
+// normalizeSynonymGroup 整理同义词组，分词后相同的词只保留一个
+func normalizeSynonymGroup(words []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, word := range normalizeDictionaryWords(words) {
+		key := strings.Join(synonymKey(word), " ")
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, word)
+	}
+	if len(result) < 2 {
+		return nil, ErrSynonymGroupTooSmall
+	}
+	return result, nil
+}
+
+// CreateSynonymGroup 新建同义词组，保存后立即在搜索中生效
+func CreateSynonymGroup(words []string) (*models.SynonymGroup, error) {
+	words, err := normalizeSynonymGroup(words)
+	if err != nil {
+		return nil, err
+	}
+	group := models.SynonymGroup{Words: words}
+	if err := db.GetDB().Create(&group).Error; err != nil {
+		return nil, err
+	}
+	if err := ReloadSearchDictionary(); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateSynonymGroup 替换同义词组中的词
+func UpdateSynonymGroup(id uint, words []string) (*models.SynonymGroup, error) {
+	words, err := normalizeSynonymGroup(words)
+	if err != nil {
+		return nil, err
+	}
+
+	var group models.SynonymGroup
+	err = db.GetDB().First(&group, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSynonymGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	group.Words = words
+	if err := db.GetDB().Save(&group).Error; err != nil {
+		return nil, err
+	}
+	if err := ReloadSearchDictionary(); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// DeleteSynonymGroup 删除同义词组
+func DeleteSynonymGroup(id uint) error {
+	result := db.GetDB().Delete(&models.SynonymGroup{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSynonymGroupNotFound
+	}
+	return ReloadSearchDictionary()
+}
+
+// ReloadSearchDictionary 从数据库重新加载自定义词和同义词，不需要重启服务
+// 自定义词先生效，同义词按新的分词方式切分，保证与查询的分词一致
+func ReloadSearchDictionary() error {
+	searchDictionaryMu.Lock()
+	defer searchDictionaryMu.Unlock()
+
+	var words []string
+	if err := db.GetDB().Model(&models.SearchWord{}).Pluck("word", &words).Error; err != nil {
+		return err
+	}
+	groups, err := ListSynonymGroups()
+	if err != nil {
+		return err
+	}
+
+	SearchTokenizer()
+	searchUserWords.SetWords(words)
+
+	synonyms := make(map[string][][]string)
+	for _, group := range groups {
+		keys := make([][]string, 0, len(group.Words))
+		for _, word := range group.Words {
+			if key := synonymKey(word); len(key) > 0 {
+				keys = append(keys, key)
+			}
+		}
+		for i, key := range keys {
+			k := strings.Join(key, " ")
+			for j, alt := range keys {
+				if i != j && strings.Join(alt, " ") != k {
+					synonyms[k] = append(synonyms[k], alt)
+				}
+			}
+		}
+	}
+
+	searchSynonyms.Lock()
+	searchSynonyms.m = synonyms
+	searchSynonyms.Unlock()
+	utils.Log("Search dictionary loaded.")
+	return nil
+}
+
+// expandSynonyms 返回一组查询词的同义词，传给 searchquery.Parse
+func expandSynonyms(words []string) [][]string {
+	searchSynonyms.RLock()
+	defer searchSynonyms.RUnlock()
+	return searchSynonyms.m[strings.Join(words, " ")]
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services/searchquery"
+
+	"gorm.io/gorm"
+)
+
+// SearchOptions 文章搜索参数
+type SearchOptions struct {
+	Query    string
+	Tags     []string
+	TagMode  string
+	Author   string     // 作者的用户名或用户 ID
+	From     *time.Time // adjust_time >= From
+	To       *time.Time // adjust_time < To
+	Sort     string     // forms.SearchSort*，默认按相关度
+	Page     int
+	PageSize int
+}
+
+// SearchHit 命中的文章，Fuzzy 为 true 表示只被模糊匹配命中
+type SearchHit struct {
+	models.Post
+	Score float64
+	Fuzzy bool
+}
+
+// SearchResult 一页搜索结果，Terms 为查询的分词结果，用于高亮；Facets 统计全部命中的文章
+type SearchResult struct {
+	Hits   []SearchHit
+	Total  int64
+	Terms  []string
+	Facets forms.SearchFacets
+}
+
+// 标签统计最多返回的标签数
+const searchTagFacetLimit = 30
+
+// fuzzySearchConfig pg_trgm 模糊匹配配置，见 search.fuzzy
+type fuzzySearchConfig struct {
+	MinResults int64   // 全文检索结果少于该数量时追加模糊匹配，0 表示关闭
+	Threshold  float64 // pg_trgm.word_similarity_threshold
+	Weight     float64 // 模糊匹配得分的权重
+	Content    bool    // 是否同时匹配正文
+}
+
+func loadFuzzySearchConfig() fuzzySearchConfig {
+	cfg := config.GetConfig()
+	fuzzy := fuzzySearchConfig{
+		MinResults: cfg.GetInt64("search.fuzzy.minResults"),
+		Threshold:  cfg.GetFloat64("search.fuzzy.threshold"),
+		Weight:     cfg.GetFloat64("search.fuzzy.weight"),
+		Content:    cfg.GetBool("search.fuzzy.content"),
+	}
+	if fuzzy.Threshold <= 0 || fuzzy.Threshold > 1 {
+		fuzzy.Threshold = 0.3
+	}
+	if fuzzy.Weight <= 0 {
+		fuzzy.Weight = 0.5
+	}
+	return fuzzy
+}
+
+// SearchPosts 全文检索已发布文章，查询语法见 searchquery 包，语法错误时返回 searchquery.ErrSyntax
+// 全文检索结果少于 search.fuzzy.minResults 时，追加 pg_trgm 模糊匹配，使拼写错误和不完整的词也能搜到文章；
+// 两种方式命中同一篇文章时得分相加，按相关度排序时全文命中的文章总是排在只被模糊匹配命中的文章前面
+func SearchPosts(opts SearchOptions) (*SearchResult, error) {
+	parsed, err := searchquery.Parse(opts.Query, SegmentText, expandSynonyms)
+	if err != nil {
+		return nil, err
+	}
+	result := &SearchResult{
+		Hits:   []SearchHit{},
+		Terms:  parsed.Terms,
+		Facets: forms.SearchFacets{Tags: []forms.TagFacet{}, Months: []forms.MonthFacet{}},
+	}
+	tsQuery := parsed.TSQuery
+	query := parsed.Text
+	fuzzy := loadFuzzySearchConfig()
+
+	filter, filterArgs := searchFilterSQL(opts, parsed)
+	hasFilters := filter != searchBaseFilter
+
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var parts []string
+		var args []any
+
+		if tsQuery == "" && query == "" {
+			if !hasFilters {
+				return nil
+			}
+			// 只有标签、作者、日期条件时列出所有符合条件的文章
+			parts = append(parts, `SELECT id, 0 AS score, true AS exact FROM posts WHERE `+filter)
+			args = append(args, filterArgs...)
+			if err := tx.Raw(`WITH matches AS (`+parts[0]+`) SELECT COUNT(*) FROM matches`, args...).Scan(&result.Total).Error; err != nil {
+				return err
+			}
+		}
+
+		if tsQuery != "" {
+			parts = append(parts, `SELECT id, ts_rank(tokens, to_tsquery('simple', ?)) AS score, true AS exact
+				FROM posts WHERE tokens @@ to_tsquery('simple', ?) AND `+filter)
+			args = append(args, tsQuery, tsQuery)
+			args = append(args, filterArgs...)
+
+			if err := tx.Raw(`WITH matches AS (`+parts[0]+`) SELECT COUNT(*) FROM matches`, args...).Scan(&result.Total).Error; err != nil {
+				return err
+			}
+		}
+
+		if query != "" && result.Total < fuzzy.MinResults {
+			// set_config 的第三个参数为 true，阈值只在当前事务内生效
+			threshold := strconv.FormatFloat(fuzzy.Threshold, 'f', -1, 64)
+			if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", threshold).Error; err != nil {
+				return err
+			}
+
+			score, where := "word_similarity(?, title)", "? <% title"
+			scoreArgs, whereArgs := []any{query}, []any{query}
+			if fuzzy.Content {
+				score = "GREATEST(word_similarity(?, title), word_similarity(?, content))"
+				where = "(? <% title OR ? <% content)"
+				scoreArgs, whereArgs = []any{query, query}, []any{query, query}
+			}
+			parts = append(parts, `SELECT id, `+score+` * ? AS score, false AS exact
+				FROM posts WHERE `+where+` AND `+filter)
+			args = append(args, scoreArgs...)
+			args = append(args, fuzzy.Weight)
+			args = append(args, whereArgs...)
+			args = append(args, filterArgs...)
+
+			matches := strings.Join(parts, " UNION ALL ")
+			if err := tx.Raw(`WITH matches AS (`+matches+`) SELECT COUNT(DISTINCT id) FROM matches`, args...).Scan(&result.Total).Error; err != nil {
+				return err
+			}
+		}
+
+		if result.Total == 0 {
+			return nil
+		}
+
+		matches := `WITH matches AS (` + strings.Join(parts, " UNION ALL ") + `)`
+		order := "r.exact DESC, r.score DESC, p.adjust_time DESC, p.id DESC"
+		switch opts.Sort {
+		case forms.SearchSortNewest:
+			order = "p.adjust_time DESC, p.id DESC"
+		case forms.SearchSortOldest:
+			order = "p.adjust_time ASC, p.id ASC"
+		}
+
+		sql := matches + `,
+			ranked AS (
+				SELECT id, SUM(score) AS score, bool_or(exact) AS exact
+				FROM matches
+				GROUP BY id
+			)
+			SELECT p.id, p.title, p.slug, p.content, p.img_url, p.adjust_time, p.toc, p.word_count, p.reading_time,
+			       r.score, NOT r.exact AS fuzzy
+			FROM ranked r
+			JOIN posts p ON p.id = r.id
+			ORDER BY ` + order + `
+			LIMIT ? OFFSET ?
+		`
+		pageArgs := append(append([]any{}, args...), opts.PageSize, (opts.Page-1)*opts.PageSize)
+		if err := tx.Raw(sql, pageArgs...).Scan(&result.Hits).Error; err != nil {
+			return err
+		}
+
+		// 统计全部命中文章的标签和发布月份
+		tagSQL := matches + `
+			SELECT t.name, t.slug, COUNT(DISTINCT m.id) AS count
+			FROM matches m
+			JOIN post_tags pt ON pt.post_id = m.id
+			JOIN tags t ON t.id = pt.tag_id AND t.deleted_at IS NULL
+			GROUP BY t.id, t.name, t.slug
+			ORDER BY count DESC, t.name ASC
+			LIMIT ?
+		`
+		tagArgs := append(append([]any{}, args...), searchTagFacetLimit)
+		if err := tx.Raw(tagSQL, tagArgs...).Scan(&result.Facets.Tags).Error; err != nil {
+			return err
+		}
+
+		monthSQL := matches + `
+			SELECT to_char(p.adjust_time, 'YYYY-MM') AS month, COUNT(*) AS count
+			FROM (SELECT DISTINCT id FROM matches) m
+			JOIN posts p ON p.id = m.id
+			GROUP BY month
+			ORDER BY month DESC
+		`
+		return tx.Raw(monthSQL, args...).Scan(&result.Facets.Months).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 翻页不重复计数；只记录普通搜索词，tag:、排除词等语法不出现在热门搜索中
+	if result.Total > 0 && opts.Page == 1 && parsed.Text != "" {
+		go recordSearchQuery(parsed.Text)
+	}
+	return result, nil
+}
+
+const searchBaseFilter = "status = ? AND deleted_at IS NULL"
+
+// searchFilterSQL 组合搜索参数和查询语法中的筛选条件
+func searchFilterSQL(opts SearchOptions, parsed *searchquery.Query) (string, []any) {
+	filter := searchBaseFilter
+	args := []any{models.PostStatusPublished}
+	add := func(sql string, vars ...any) {
+		filter += " AND " + sql
+		args = append(args, vars...)
+	}
+
+	if tagSQL, tagArgs := TagFilterSQL("id", opts.Tags, opts.TagMode); tagSQL != "" {
+		add(tagSQL, tagArgs...)
+	}
+	// 查询语法中的 tag: 条件需要全部满足
+	if tagSQL, tagArgs := TagFilterSQL("id", parsed.Tags, TagMatchAll); tagSQL != "" {
+		add(tagSQL, tagArgs...)
+	}
+	if tagSQL, tagArgs := TagFilterSQL("id", parsed.ExcludeTags, TagMatchAny); tagSQL != "" {
+		add("NOT "+tagSQL, tagArgs...)
+	}
+	if author := strings.TrimSpace(opts.Author); author != "" {
+		add(`author_id IN (SELECT CAST(id AS text) FROM "TableUsers" WHERE (name = ? OR CAST(id AS text) = ?) AND deleted_at IS NULL)`, author, author)
+	}
+	for _, after := range []*time.Time{opts.From, parsed.After} {
+		if after != nil {
+			add("adjust_time >= ?", *after)
+		}
+	}
+	for _, before := range []*time.Time{opts.To, parsed.Before} {
+		if before != nil {
+			add("adjust_time < ?", *before)
+		}
+	}
+	return filter, args
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"blog-server/services/searchquery"
+	"blog-server/services/tokenizer"
+	"blog-server/utils"
+)
+
+// 查询词经过词干提取后，原文中的原词仍然能被高亮
+func TestSearchTermsHighlightWithStemming(t *testing.T) {
+	p := &tokenizer.Pipeline{Backend: tokenizer.Bigram{}, Stem: true, StopWords: true}
+	opts := utils.HighlightOptions{StartSel: "[", StopSel: "]", Normalize: p.Normalize}
+
+	tests := []struct {
+		query string
+		text  string
+		want  string
+	}{
+		{query: "Kubernetes running databases", text: "Running Databases on Kubernetes", want: "[Running] [Databases] on [Kubernetes]"},
+		{query: "database", text: "Two databases, one database", want: "Two [databases], one [database]"},
+		{query: "runs 并发", text: "He ran; it runs 并发编程", want: "He ran; it [runs] [并发]编程"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			parsed, err := searchquery.Parse(tt.query, p.Tokenize, nil)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.query, err)
+			}
+			if got := utils.Highlight(tt.text, parsed.Terms, opts); got != tt.want {
+				t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, parsed.Terms, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// Package searchquery 解析文章搜索的查询语法
+//
+//	垃圾回收 GC           两个词都要出现
+//	"垃圾回收"            短语，词按顺序相邻出现
+//	golang | rust         任意一个出现，也可以写作 golang OR rust
+//	-java                 不包含 java
+//	title:并发            只匹配标题
+//	tag:go -tag:draft     包含 / 不包含标签
+//	after:2024-01-01      发布时间不早于该日期
+//	before:2024-12-31     发布时间不晚于该日期
+//
+// 传入 Expander 时，搜索词会和它的同义词组成 OR，如 k8s 展开为 (k8s | kubernetes)
+package searchquery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrSyntax 查询语法错误，调用方应返回 400
+var ErrSyntax = errors.New("搜索语法错误")
+
+const dateLayout = "2006-01-02"
+
+// 支持的字段前缀，其他形如 a:b 的词按普通文本处理
+var fields = map[string]bool{"title": true, "tag": true, "after": true, "before": true}
+
+// Query 解析后的搜索条件
+type Query struct {
+	TSQuery     string     // 可直接传给 to_tsquery('simple', ?) 的查询，没有全文条件时为空
+	Text        string     // 未限定字段、未排除的普通搜索词，用于模糊匹配
+	Terms       []string   // 需要高亮的词
+	Tags        []string   // 必须包含的标签
+	ExcludeTags []string   // 不能包含的标签
+	After       *time.Time // adjust_time >= After
+	Before      *time.Time // adjust_time < Before
+}
+
+// HasFilters 是否有标签或日期条件
+func (q *Query) HasFilters() bool {
+	return len(q.Tags) > 0 || len(q.ExcludeTags) > 0 || q.After != nil || q.Before != nil
+}
+
+// Expander 返回一组词（分词结果）的同义词，每个同义词也是分词后的词序列，没有同义词时返回 nil
+type Expander func(words []string) [][]string
+
+type token struct {
+	or      bool
+	negated bool
+	field   string
+	value   string
+	phrase  bool
+}
+
+// Parse 解析查询，segment 用于把文本切分成词，与生成 tokens 时的分词方式一致；expand 可以为 nil
+func Parse(input string, segment func(string) []string, expand Expander) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+	var groups [][]string // 组内 OR，组间 AND
+	var text []string
+	positive, negative := 0, 0
+	pendingOr, lastText := false, false
+
+	for _, t := range tokens {
+		if t.or {
+			if !lastText || pendingOr {
+				return nil, syntaxError("OR 前缺少搜索词")
+			}
+			pendingOr = true
+			continue
+		}
+
+		switch t.field {
+		case "tag":
+			if pendingOr {
+				return nil, syntaxError("tag: 条件不能使用 OR")
+			}
+			if t.negated {
+				q.ExcludeTags = append(q.ExcludeTags, t.value)
+				negative++
+			} else {
+				q.Tags = append(q.Tags, t.value)
+				positive++
+			}
+			lastText = false
+
+		case "after", "before":
+			if pendingOr {
+				return nil, syntaxError(t.field + ": 条件不能使用 OR")
+			}
+			if t.negated {
+				return nil, syntaxError(t.field + ": 条件不能排除")
+			}
+			date, err := time.ParseInLocation(dateLayout, strings.TrimSpace(t.value), time.Local)
+			if err != nil {
+				return nil, syntaxError(fmt.Sprintf("%s:%s 日期格式应为 YYYY-MM-DD", t.field, t.value))
+			}
+			if t.field == "after" {
+				q.After = &date
+			} else {
+				// before 包含当天
+				end := date.AddDate(0, 0, 1)
+				q.Before = &end
+			}
+			positive++
+			lastText = false
+
+		default:
+			words := lexemes(segment(t.value))
+			if len(words) == 0 {
+				// 只有标点等无法检索的内容，直接忽略
+				continue
+			}
+			expr, synonyms := buildExpr(words, t, expand)
+			if pendingOr {
+				groups[len(groups)-1] = append(groups[len(groups)-1], expr)
+				pendingOr = false
+			} else {
+				groups = append(groups, []string{expr})
+			}
+			lastText = true
+
+			if t.negated {
+				negative++
+				continue
+			}
+			positive++
+			q.Terms = append(q.Terms, words...)
+			q.Terms = append(q.Terms, synonyms...)
+			if t.field == "" {
+				text = append(text, strings.TrimSpace(t.value))
+			}
+		}
+	}
+	if pendingOr {
+		return nil, syntaxError("OR 后缺少搜索词")
+	}
+	if positive == 0 && negative > 0 {
+		return nil, syntaxError("不能只包含排除条件")
+	}
+	if q.After != nil && q.Before != nil && !q.After.Before(*q.Before) {
+		return nil, syntaxError("after: 不能晚于 before:")
+	}
+
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		if len(group) == 1 {
+			parts[i] = group[0]
+		} else {
+			parts[i] = "(" + strings.Join(group, " | ") + ")"
+		}
+	}
+	q.TSQuery = strings.Join(parts, " & ")
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// buildExpr 把一个词条的分词结果组合成 tsquery 片段，同时返回展开出的同义词
+// 整个词条有同义词时和同义词组成 OR；未加引号的多个词还会逐个展开
+func buildExpr(words []string, t token, expand Expander) (string, []string) {
+	suffix := ""
+	if t.field == "title" {
+		// 标题在 tokens 中的权重为 A
+		suffix = ":A"
+	}
+	var synonyms []string
+	// alternatives 返回 ws 本身以及它的同义词，同义词是固定的短语，用 <-> 连接
+	alternatives := func(ws []string, op string) []string {
+		parts := make([]string, len(ws))
+		for i, w := range ws {
+			parts[i] = w + suffix
+		}
+		result := []string{group(parts, op)}
+		if expand == nil {
+			return result
+		}
+		for _, alt := range expand(ws) {
+			alt = lexemes(alt)
+			if len(alt) == 0 {
+				continue
+			}
+			synonyms = append(synonyms, alt...)
+			parts := make([]string, len(alt))
+			for i, w := range alt {
+				parts[i] = w + suffix
+			}
+			result = append(result, group(parts, " <-> "))
+		}
+		return result
+	}
+
+	op := " & "
+	if t.phrase {
+		op = " <-> "
+	}
+	whole := alternatives(words, op)
+	if !t.phrase && len(words) > 1 && expand != nil {
+		parts := make([]string, len(words))
+		for i, w := range words {
+			parts[i] = group(alternatives([]string{w}, op), " | ")
+		}
+		whole[0] = group(parts, op)
+	}
+	base := group(whole, " | ")
+
+	if t.negated {
+		base = "!" + base
+	}
+	return base, synonyms
+}
+
+// group 用 op 连接多个片段，多于一个时加括号
+func group(parts []string, op string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, op) + ")"
+}
+
+// lexemes 去掉分词结果中 tsquery 的运算符和标点，只保留字母和数字
+func lexemes(words []string) []string {
+	var result []string
+	for _, w := range words {
+		if w = Lexeme(w); w != "" {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// Lexeme 只保留词中的字母和数字，结果可以安全地拼接进 tsquery
+func Lexeme(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+func isBoundary(r rune) bool {
+	return unicode.IsSpace(r) || r == '|' || r == '"'
+}
+
+// lex 把输入切分为词条和 OR
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		if runes[i] == '|' {
+			tokens = append(tokens, token{or: true})
+			i++
+			continue
+		}
+
+		var t token
+		if runes[i] == '-' && i+1 < len(runes) && (!isBoundary(runes[i+1]) || runes[i+1] == '"') {
+			t.negated = true
+			i++
+		}
+
+		// 字段前缀
+		start := i
+		for i < len(runes) && !isBoundary(runes[i]) && runes[i] != ':' {
+			i++
+		}
+		if name := strings.ToLower(string(runes[start:i])); i < len(runes) && runes[i] == ':' && fields[name] {
+			t.field = name
+			i++
+		} else {
+			i = start
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, syntaxError("引号未闭合")
+			}
+			t.value = string(runes[i+1 : end])
+			t.phrase = true
+			i = end + 1
+		} else {
+			start = i
+			for i < len(runes) && !isBoundary(runes[i]) {
+				i++
+			}
+			t.value = string(runes[start:i])
+		}
+
+		if t.field == "" && !t.negated && !t.phrase && t.value == "OR" {
+			tokens = append(tokens, token{or: true})
+			continue
+		}
+		if t.field != "" && strings.TrimSpace(t.value) == "" {
+			return nil, syntaxError(t.field + ": 后缺少内容")
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+func syntaxError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrSyntax, msg)
+}
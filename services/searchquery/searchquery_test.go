@@ -0,0 +1,126 @@
+package searchquery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 测试用的分词：按空格切分，连续的汉字每两个字一个词
+func segment(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		runes := []rune(field)
+		if len(runes) > 1 && runes[0] > 0x2e80 {
+			for i := 0; i < len(runes); i += 2 {
+				words = append(words, string(runes[i:min(i+2, len(runes))]))
+			}
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		text    string
+		tags    []string
+		exclude []string
+	}{
+		{name: "and", input: "golang 并发", want: "golang & 并发", text: "golang 并发"},
+		{name: "phrase", input: `"垃圾回收"`, want: "(垃圾 <-> 回收)", text: "垃圾回收"},
+		{name: "or", input: "golang | rust OR c", want: "(golang | rust | c)", text: "golang rust c"},
+		{name: "exclude", input: "golang -java", want: "golang & !java", text: "golang"},
+		{name: "title", input: `title:"垃圾回收" gc`, want: "(垃圾:A <-> 回收:A) & gc", text: "gc"},
+		{name: "tags", input: "gc tag:Go -tag:draft", want: "gc", text: "gc", tags: []string{"Go"}, exclude: []string{"draft"}},
+		{name: "operators stripped", input: "a&b : c:d ! (e)", want: "ab & cd & e", text: "a&b c:d (e)"},
+		{name: "empty", input: "   ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.input, segment, nil)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if q.TSQuery != tt.want {
+				t.Errorf("TSQuery = %q, want %q", q.TSQuery, tt.want)
+			}
+			if q.Text != tt.text {
+				t.Errorf("Text = %q, want %q", q.Text, tt.text)
+			}
+			if strings.Join(q.Tags, ",") != strings.Join(tt.tags, ",") || strings.Join(q.ExcludeTags, ",") != strings.Join(tt.exclude, ",") {
+				t.Errorf("Tags = %v / %v, want %v / %v", q.Tags, q.ExcludeTags, tt.tags, tt.exclude)
+			}
+		})
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	q, err := Parse("after:2024-01-01 before:2024-01-31", segment, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.After == nil || q.After.Format(dateLayout) != "2024-01-01" {
+		t.Errorf("After = %v", q.After)
+	}
+	if q.Before == nil || q.Before.Format(dateLayout) != "2024-02-01" {
+		t.Errorf("Before = %v, want the day after 2024-01-31", q.Before)
+	}
+	if q.TSQuery != "" || !q.HasFilters() {
+		t.Errorf("TSQuery = %q, HasFilters = %v", q.TSQuery, q.HasFilters())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		`"垃圾回收`,
+		"| golang",
+		"golang |",
+		"golang | | rust",
+		"title:",
+		"after:2024/01/01",
+		"-after:2024-01-01",
+		"golang | tag:go",
+		"-java",
+		"after:2024-02-01 before:2024-01-01",
+	}
+	for _, input := range inputs {
+		if _, err := Parse(input, segment, nil); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error = %v, want ErrSyntax", input, err)
+		}
+	}
+}
+
+func TestParseSynonyms(t *testing.T) {
+	synonyms := map[string][][]string{
+		"k8s":   {{"kubernetes"}},
+		"gc":    {{"垃圾", "回收"}},
+		"垃圾 回收": {{"gc"}},
+		"回收":    {{"recycle"}},
+	}
+	expand := func(words []string) [][]string { return synonyms[strings.Join(words, " ")] }
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "k8s", want: "(k8s | kubernetes)"},
+		{input: "gc -k8s", want: "(gc | (垃圾 <-> 回收)) & !(k8s | kubernetes)"},
+		{input: `"垃圾回收"`, want: "((垃圾 <-> 回收) | gc)"},
+		{input: "垃圾回收", want: "((垃圾 & (回收 | recycle)) | gc)"},
+		{input: "title:k8s", want: "(k8s:A | kubernetes:A)"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.input, segment, expand)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.input, err)
+		}
+		if q.TSQuery != tt.want {
+			t.Errorf("Parse(%q).TSQuery = %q, want %q", tt.input, q.TSQuery, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"blog-server/config"
+	"blog-server/models"
+)
+
+// SiteURL 返回配置的站点地址（不带结尾的 /）
+func SiteURL() string {
+	return strings.TrimRight(config.GetConfig().GetString("site.url"), "/")
+}
+
+// PostURL 返回文章详情页的绝对地址，没有 slug 的老文章退回使用 ID
+func PostURL(post models.Post) string {
+	postPath := config.GetConfig().GetString("site.postPath")
+	if postPath == "" {
+		postPath = "/posts/"
+	}
+
+	key := post.Slug
+	if key == "" {
+		key = strconv.FormatUint(uint64(post.ID), 10)
+	}
+	return SiteURL() + postPath + url.PathEscape(key)
+}
+
+// TagURL 返回标签页的绝对地址
+func TagURL(name string) string {
+	return SiteURL() + "/tags/" + url.PathEscape(name)
+}
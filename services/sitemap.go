@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/models"
+)
+
+// SitemapMaxURLs 单个 sitemap 文件允许的最大 URL 数（sitemaps.org 协议限制）
+const SitemapMaxURLs = 50000
+
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name        `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURLXML `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name        `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapURLXML `xml:"sitemap"`
+}
+
+type sitemapURLXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapStats 已发布文章数和最近更新时间，用于决定是否拆分 sitemap 以及缓存校验
+func SitemapStats() (int64, time.Time, error) {
+	var stats struct {
+		Total   int64
+		LastMod *time.Time
+	}
+	err := db.GetDB().Model(&models.Post{}).
+		Scopes(PublishedPosts).
+		Select("COUNT(*) AS total, MAX(updated_at) AS last_mod").
+		Scan(&stats).Error
+	if err != nil || stats.LastMod == nil {
+		return stats.Total, time.Time{}, err
+	}
+	return stats.Total, *stats.LastMod, nil
+}
+
+// SitemapPostURLs 按 ID 顺序分页获取已发布文章的 sitemap 条目
+func SitemapPostURLs(offset, limit int) ([]SitemapURL, error) {
+	var posts []models.Post
+	err := db.GetDB().
+		Scopes(PublishedPosts).
+		Select("id", "slug", "updated_at").
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]SitemapURL, len(posts))
+	for i, post := range posts {
+		urls[i] = SitemapURL{Loc: PostURL(post), LastMod: post.UpdatedAt}
+	}
+	return urls, nil
+}
+
+// SitemapTagURLs 获取有已发布文章的标签页，lastmod 取标签下文章的最近更新时间
+func SitemapTagURLs() ([]SitemapURL, error) {
+	var rows []struct {
+		Name    string
+		LastMod time.Time
+	}
+	err := db.GetDB().Table("tags AS t").
+		Select("t.name, MAX(p.updated_at) AS last_mod").
+		Joins("JOIN post_tags pt ON pt.tag_id = t.id").
+		Joins("JOIN posts p ON p.id = pt.post_id").
+		Where("p.status = ? AND p.deleted_at IS NULL AND t.deleted_at IS NULL", models.PostStatusPublished).
+		Group("t.name").
+		Order("t.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]SitemapURL, len(rows))
+	for i, row := range rows {
+		urls[i] = SitemapURL{Loc: TagURL(row.Name), LastMod: row.LastMod}
+	}
+	return urls, nil
+}
+
+// BuildSitemap 生成 urlset 文档
+func BuildSitemap(urls []SitemapURL) ([]byte, error) {
+	set := sitemapURLSet{URLs: make([]sitemapURLXML, len(urls))}
+	for i, u := range urls {
+		set.URLs[i] = toSitemapURLXML(u)
+	}
+	return marshalXML(set)
+}
+
+// BuildSitemapIndex 生成 sitemapindex 文档
+func BuildSitemapIndex(sitemaps []SitemapURL) ([]byte, error) {
+	index := sitemapIndex{Sitemaps: make([]sitemapURLXML, len(sitemaps))}
+	for i, u := range sitemaps {
+		index.Sitemaps[i] = toSitemapURLXML(u)
+	}
+	return marshalXML(index)
+}
+
+func toSitemapURLXML(u SitemapURL) sitemapURLXML {
+	x := sitemapURLXML{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		x.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+	return x
+}
+
+// BuildRobotsTxt 根据配置生成 robots.txt
+func BuildRobotsTxt() string {
+	cfg := config.GetConfig()
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, path := range cfg.GetStringSlice("robots.allow") {
+		fmt.Fprintf(&b, "Allow: %s\n", path)
+	}
+	for _, path := range cfg.GetStringSlice("robots.disallow") {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", SiteURL())
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/utils"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 标题无法生成 slug 时（例如全是符号）使用的默认前缀
+	defaultSlug = "post"
+	// posts.slug 的唯一索引，见 db.EnsureSlugIndex
+	postSlugIndex       = "idx_posts_slug"
+	maxPostSlugAttempts = 3
+)
+
+// GeneratePostSlug 根据手动指定的 slug 或标题生成唯一 slug
+// excludeID 为当前文章 ID，更新时避免与自身冲突；新建文章传 0
+func GeneratePostSlug(tx *gorm.DB, title, requested string, excludeID uint) (string, error) {
+	base := utils.Slugify(requested)
+	if base == "" {
+		base = utils.Slugify(title)
+	}
+	if base == "" {
+		base = defaultSlug
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		err := tx.Unscoped().Model(&models.Post{}).
+			Where("slug = ? AND id <> ?", slug, excludeID).
+			Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// SavePostWithSlug 生成唯一 slug 后调用 save 保存文章，并发保存的文章抢先占用同一 slug 时重新生成后重试
+// 插入失败会中止 PostgreSQL 事务，所以每次尝试都包在保存点中；save 负责把 slug 写入文章并保存
+func SavePostWithSlug(tx *gorm.DB, title, requested string, excludeID uint, save func(slug string) error) error {
+	for attempt := 1; ; attempt++ {
+		slug, err := GeneratePostSlug(tx, title, requested, excludeID)
+		if err != nil {
+			return err
+		}
+		savepoint := fmt.Sprintf("post_slug_%d", attempt)
+		if err := tx.SavePoint(savepoint).Error; err != nil {
+			return err
+		}
+		err = save(slug)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation &&
+			pgErr.ConstraintName == postSlugIndex && attempt < maxPostSlugAttempts {
+			if err := tx.RollbackTo(savepoint).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		return err
+	}
+}
+
+// ApplyPostSlug 修改文章 slug，并把旧 slug 记录为跳转
+// 调用方负责保存 post
+func ApplyPostSlug(tx *gorm.DB, post *models.Post, slug string) error {
+	if post.Slug == slug {
+		return nil
+	}
+
+	if post.Slug != "" && post.ID != 0 {
+		redirect := models.PostSlugRedirect{OldSlug: post.Slug, PostID: post.ID}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "old_slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"post_id"}),
+		}).Create(&redirect).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 新 slug 曾经是某篇文章的旧地址时，以当前文章为准
+	if err := tx.Where("old_slug = ?", slug).Delete(&models.PostSlugRedirect{}).Error; err != nil {
+		return err
+	}
+
+	post.Slug = slug
+	return nil
+}
+
+// ResolveSlugRedirect 查询旧 slug 对应文章的当前 slug，viewer 看不到的文章视为不存在
+func ResolveSlugRedirect(viewer Viewer, oldSlug string) (string, error) {
+	var slugs []string
+	err := db.GetDB().Model(&models.Post{}).
+		Scopes(PostVisibility(viewer, "")).
+		Where("id IN (?)", db.GetDB().Model(&models.PostSlugRedirect{}).Select("post_id").Where("old_slug = ?", oldSlug)).
+		Limit(1).
+		Pluck("slug", &slugs).Error
+	if err != nil {
+		return "", err
+	}
+	if len(slugs) == 0 || slugs[0] == "" {
+		return "", gorm.ErrRecordNotFound
+	}
+	return slugs[0], nil
+}
+
+// BackfillPostSlugs 为还没有 slug 的老文章生成 slug
+func BackfillPostSlugs() error {
+	var posts []models.Post
+	if err := db.GetDB().Unscoped().Where("slug = ''").Order("id ASC").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+			slug, err := GeneratePostSlug(tx, post.Title, "", post.ID)
+			if err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&post).UpdateColumn("slug", slug).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to backfill slug for post ID %d: %w", post.ID, err)
+		}
+	}
+
+	if len(posts) > 0 {
+		utils.Log("Post slugs backfilled: ", len(posts))
+	}
+	return nil
+}
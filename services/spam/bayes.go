@@ -0,0 +1,207 @@
+package spam
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DocumentsToken 记录已学习的垃圾/正常评论篇数，分词结果不会出现 #
+const DocumentsToken = "#documents"
+
+// TokenCount 某个词在垃圾/正常评论中出现的篇数
+type TokenCount struct {
+	Spam int
+	Ham  int
+}
+
+// BayesStore 持久化贝叶斯词频，Save 传入的是增量
+type BayesStore interface {
+	Load() (map[string]TokenCount, error)
+	Save(delta map[string]TokenCount) error
+}
+
+// Bayes 朴素贝叶斯分类器，用审核员的通过/拒绝结果训练
+type Bayes struct {
+	// 两类评论都至少学习了这么多篇之后才开始判断
+	MinDocuments int
+	// 垃圾概率不低于该值时判为垃圾评论
+	SpamThreshold float64
+	// 可疑概率不低于该值时要求人工审核，为 0 时不使用
+	PendingThreshold float64
+	Store            BayesStore
+
+	mu     sync.RWMutex
+	counts map[string]TokenCount
+}
+
+// NewBayes 从 store 加载已有词频，store 为 nil 时只保存在内存中
+func NewBayes(store BayesStore) (*Bayes, error) {
+	b := &Bayes{
+		MinDocuments:  20,
+		SpamThreshold: 0.9,
+		Store:         store,
+		counts:        make(map[string]TokenCount),
+	}
+	if store != nil {
+		counts, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		b.counts = counts
+	}
+	return b, nil
+}
+
+func (b *Bayes) Name() string { return "bayes" }
+
+// Tokenize 把评论拆成去重后的词：英文数字按单词切分并转小写，中日韩文字按相邻两字切分
+func Tokenize(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(t string) {
+		if t != "" && !seen[t] && len([]rune(t)) <= 40 {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		add(strings.ToLower(string(word)))
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+func candidateTokens(c *Candidate) []string {
+	return Tokenize(strings.Join([]string{c.AuthorName, c.AuthorEmail, c.AuthorWebsite, c.Content}, " "))
+}
+
+// Probability 返回评论是垃圾评论的概率；训练样本不足时 ok 为 false
+func (b *Bayes) Probability(c *Candidate) (p float64, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	docs := b.counts[DocumentsToken]
+	if docs.Spam < b.MinDocuments || docs.Ham < b.MinDocuments || docs.Spam == 0 || docs.Ham == 0 {
+		return 0, false
+	}
+
+	// Robinson 平滑：出现次数少的词向 0.5 靠拢
+	const strength, prior = 1.0, 0.5
+	var probs []float64
+	for _, t := range candidateTokens(c) {
+		count, seen := b.counts[t]
+		if !seen {
+			continue
+		}
+		spamFreq := float64(count.Spam) / float64(docs.Spam)
+		hamFreq := float64(count.Ham) / float64(docs.Ham)
+		if spamFreq+hamFreq == 0 {
+			continue
+		}
+		n := float64(count.Spam + count.Ham)
+		raw := spamFreq / (spamFreq + hamFreq)
+		probs = append(probs, (strength*prior+n*raw)/(strength+n))
+	}
+	if len(probs) == 0 {
+		return 0.5, true
+	}
+
+	// 只取最有区分度的 15 个词
+	sort.Slice(probs, func(i, j int) bool {
+		return math.Abs(probs[i]-0.5) > math.Abs(probs[j]-0.5)
+	})
+	if len(probs) > 15 {
+		probs = probs[:15]
+	}
+
+	var logSpam, logHam float64
+	for _, p := range probs {
+		logSpam += math.Log(p)
+		logHam += math.Log(1 - p)
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam)), true
+}
+
+func (b *Bayes) Check(_ context.Context, c *Candidate) (Result, error) {
+	p, ok := b.Probability(c)
+	switch {
+	case !ok:
+		return Result{Verdict: VerdictHam}, nil
+	case p >= b.SpamThreshold:
+		return Result{Verdict: VerdictSpam, Reason: "贝叶斯分类器判定为垃圾评论"}, nil
+	case b.PendingThreshold > 0 && p >= b.PendingThreshold:
+		return Result{Verdict: VerdictPending, Reason: "贝叶斯分类器判定为可疑评论"}, nil
+	}
+	return Result{Verdict: VerdictHam}, nil
+}
+
+// train 给评论中的每个词加上 step（+1 学习，-1 撤销）
+func (b *Bayes) train(c *Candidate, spam bool, step int) error {
+	delta := make(map[string]TokenCount)
+	for _, t := range append(candidateTokens(c), DocumentsToken) {
+		if spam {
+			delta[t] = TokenCount{Spam: step}
+		} else {
+			delta[t] = TokenCount{Ham: step}
+		}
+	}
+
+	b.mu.Lock()
+	for t, d := range delta {
+		count := b.counts[t]
+		count.Spam = max(count.Spam+d.Spam, 0)
+		count.Ham = max(count.Ham+d.Ham, 0)
+		if count.Spam == 0 && count.Ham == 0 {
+			delete(b.counts, t)
+		} else {
+			b.counts[t] = count
+		}
+	}
+	b.mu.Unlock()
+
+	if b.Store == nil {
+		return nil
+	}
+	return b.Store.Save(delta)
+}
+
+func (b *Bayes) Learn(_ context.Context, c *Candidate, spam bool) error {
+	return b.train(c, spam, 1)
+}
+
+func (b *Bayes) Unlearn(_ context.Context, c *Candidate, spam bool) error {
+	return b.train(c, spam, -1)
+}
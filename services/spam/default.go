@@ -0,0 +1,106 @@
+package spam
+
+import (
+	"context"
+	"crypto/rand"
+	"log"
+	"sync"
+	"time"
+
+	"blog-server/config"
+)
+
+var (
+	defaultChain     *Chain
+	defaultFormToken *FormToken
+	defaultOnce      sync.Once
+)
+
+func durationOr(key string, fallback time.Duration) time.Duration {
+	if d := config.GetConfig().GetDuration(key); d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// load 根据 spam.* 配置组装检查链，首次使用时调用
+func load() {
+	cfg := config.GetConfig()
+
+	secret := []byte(cfg.GetString("spam.tokenSecret"))
+	if len(secret) == 0 {
+		// 未配置时每次启动随机生成，重启前签发的表单令牌会失效
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	defaultFormToken = &FormToken{
+		Secret: secret,
+		MinAge: durationOr("spam.minSubmitTime", 3*time.Second),
+		MaxAge: durationOr("spam.formTokenTTL", 2*time.Hour),
+	}
+
+	rateLimit := cfg.GetInt("spam.rateLimit.count")
+	if rateLimit <= 0 {
+		rateLimit = 5
+	}
+
+	checkers := []Checker{
+		&RateLimit{Limit: rateLimit, Window: durationOr("spam.rateLimit.window", 10*time.Minute)},
+		Honeypot{},
+		defaultFormToken,
+		BlockedWords{Words: cfg.GetStringSlice("spam.blockedWords")},
+	}
+	// 未配置或 <= 0 时不限制链接数
+	if maxLinks := cfg.GetInt("spam.maxLinks"); maxLinks > 0 {
+		checkers = append(checkers, LinkCount{Max: maxLinks})
+	}
+
+	if cfg.GetBool("spam.bayes.enabled") {
+		bayes, err := NewBayes(DBStore{})
+		if err != nil {
+			log.Printf("failed to load spam classifier: %v", err)
+		} else {
+			if n := cfg.GetInt("spam.bayes.minDocuments"); n > 0 {
+				bayes.MinDocuments = n
+			}
+			if t := cfg.GetFloat64("spam.bayes.spamThreshold"); t > 0 {
+				bayes.SpamThreshold = t
+			}
+			bayes.PendingThreshold = cfg.GetFloat64("spam.bayes.pendingThreshold")
+			checkers = append(checkers, bayes)
+		}
+	}
+
+	if cfg.GetBool("spam.remote.enabled") {
+		checkers = append(checkers, NewRemote(
+			cfg.GetString("spam.remote.endpoint"),
+			cfg.GetString("spam.remote.apiKey"),
+			cfg.GetString("site.url"),
+			durationOr("spam.remote.timeout", 3*time.Second),
+		))
+	}
+
+	defaultChain = NewChain(checkers...)
+}
+
+// Default 返回按配置组装的检查链
+func Default() *Chain {
+	defaultOnce.Do(load)
+	return defaultChain
+}
+
+// IssueFormToken 为文章签发评论表单令牌
+func IssueFormToken(postID uint) string {
+	defaultOnce.Do(load)
+	return defaultFormToken.Issue(postID)
+}
+
+// Check 使用默认检查链检查评论
+func Check(ctx context.Context, c *Candidate) Result {
+	return Default().Check(ctx, c)
+}
+
+// Learn 把审核结论反馈给默认检查链
+func Learn(ctx context.Context, c *Candidate, spam bool, previous *bool) {
+	Default().Learn(ctx, c, spam, previous)
+}
@@ -0,0 +1,55 @@
+package spam
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit 限制同一 IP 在时间窗口内的提交次数，超出的请求不计入窗口
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+	// 测试时替换当前时间
+	Now func() time.Time
+
+	mu      sync.Mutex
+	history map[string][]time.Time
+}
+
+func (r *RateLimit) Name() string { return "rate_limit" }
+
+func (r *RateLimit) Check(_ context.Context, c *Candidate) (Result, error) {
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.history == nil {
+		r.history = make(map[string][]time.Time)
+	}
+
+	// 顺便清理所有过期记录，避免 map 无限增长
+	cutoff := now.Add(-r.Window)
+	for ip, times := range r.history {
+		kept := times[:0]
+		for _, t := range times {
+			if t.After(cutoff) {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.history, ip)
+		} else {
+			r.history[ip] = kept
+		}
+	}
+
+	if len(r.history[c.IP]) >= r.Limit {
+		return Result{Verdict: VerdictThrottle, Reason: "评论过于频繁"}, nil
+	}
+	r.history[c.IP] = append(r.history[c.IP], now)
+	return Result{Verdict: VerdictHam}, nil
+}
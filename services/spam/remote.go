@@ -0,0 +1,96 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Remote 兼容 Akismet 协议的远程检查服务
+// comment-check 返回 true 表示垃圾评论，submit-spam / submit-ham 用于反馈审核结果
+type Remote struct {
+	// 服务地址，如 https://<key>.rest.akismet.com/1.1
+	Endpoint string
+	APIKey   string
+	// 站点地址，对应 Akismet 的 blog 参数
+	Blog   string
+	Client *http.Client
+}
+
+// NewRemote 创建远程检查器，timeout 为单次请求超时
+func NewRemote(endpoint, apiKey, blog string, timeout time.Duration) *Remote {
+	return &Remote{
+		Endpoint: strings.TrimRight(endpoint, "/"),
+		APIKey:   apiKey,
+		Blog:     blog,
+		Client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (r *Remote) Name() string { return "remote" }
+
+func (r *Remote) form(c *Candidate) url.Values {
+	return url.Values{
+		"api_key":              {r.APIKey},
+		"blog":                 {r.Blog},
+		"user_ip":              {c.IP},
+		"user_agent":           {c.UserAgent},
+		"referrer":             {c.Referrer},
+		"permalink":            {c.Permalink},
+		"comment_type":         {"comment"},
+		"comment_author":       {c.AuthorName},
+		"comment_author_email": {c.AuthorEmail},
+		"comment_author_url":   {c.AuthorWebsite},
+		"comment_content":      {c.Content},
+	}
+}
+
+func (r *Remote) post(ctx context.Context, method string, c *Candidate) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Endpoint+"/"+method, strings.NewReader(r.form(c).Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: unexpected status %d", method, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (r *Remote) Check(ctx context.Context, c *Candidate) (Result, error) {
+	body, err := r.post(ctx, "comment-check", c)
+	if err != nil {
+		return Result{}, err
+	}
+	switch body {
+	case "true":
+		return Result{Verdict: VerdictSpam, Reason: "远程服务判定为垃圾评论"}, nil
+	case "false":
+		return Result{Verdict: VerdictHam}, nil
+	}
+	return Result{}, fmt.Errorf("comment-check: unexpected response %q", body)
+}
+
+func (r *Remote) Learn(ctx context.Context, c *Candidate, spam bool) error {
+	method := "submit-ham"
+	if spam {
+		method = "submit-spam"
+	}
+	_, err := r.post(ctx, method, c)
+	return err
+}
@@ -0,0 +1,93 @@
+package spam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStubServer 模拟 Akismet：正文包含 viagra 时判为垃圾评论，并记录反馈接口的调用
+func newStubServer(t *testing.T, submitted *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		if r.PostForm.Get("api_key") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/comment-check":
+			if strings.Contains(r.PostForm.Get("comment_content"), "viagra") {
+				w.Write([]byte("true"))
+			} else {
+				w.Write([]byte("false"))
+			}
+		case "/submit-spam", "/submit-ham":
+			*submitted = append(*submitted, strings.TrimPrefix(r.URL.Path, "/"))
+			w.Write([]byte("Thanks for making the web a better place."))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRemote(t *testing.T) {
+	var submitted []string
+	server := newStubServer(t, &submitted)
+	remote := NewRemote(server.URL, "test-key", "http://blog.example", time.Second)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		content string
+		want    Verdict
+	}{
+		{name: "Spam comment", content: "cheap viagra", want: VerdictSpam},
+		{name: "Normal comment", content: "nice post", want: VerdictHam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := remote.Check(ctx, &Candidate{Content: tt.content})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got.Verdict != tt.want {
+				t.Errorf("Check() = %v, want %v", got.Verdict, tt.want)
+			}
+		})
+	}
+
+	if err := remote.Learn(ctx, &Candidate{Content: "nice post"}, true); err != nil {
+		t.Fatalf("Learn() error = %v", err)
+	}
+	if err := remote.Learn(ctx, &Candidate{Content: "nice post"}, false); err != nil {
+		t.Fatalf("Learn() error = %v", err)
+	}
+	if len(submitted) != 2 || submitted[0] != "submit-spam" || submitted[1] != "submit-ham" {
+		t.Errorf("submitted = %v, want [submit-spam submit-ham]", submitted)
+	}
+}
+
+func TestRemote_FailsOpen(t *testing.T) {
+	var submitted []string
+	server := newStubServer(t, &submitted)
+	remote := NewRemote(server.URL, "wrong-key", "http://blog.example", time.Second)
+
+	if _, err := remote.Check(context.Background(), &Candidate{Content: "cheap viagra"}); err == nil {
+		t.Error("Check() with invalid key returned no error")
+	}
+
+	// 远程服务出错时检查链忽略该结果
+	got := NewChain(remote).Check(context.Background(), &Candidate{Content: "cheap viagra"})
+	if got.Verdict != VerdictHam {
+		t.Errorf("Chain.Check() = %v, want ham", got.Verdict)
+	}
+}
@@ -0,0 +1,122 @@
+package spam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Honeypot 蜜罐字段被填写时拒绝
+type Honeypot struct{}
+
+func (Honeypot) Name() string { return "honeypot" }
+
+func (Honeypot) Check(_ context.Context, c *Candidate) (Result, error) {
+	if strings.TrimSpace(c.Honeypot) != "" {
+		return Result{Verdict: VerdictReject, Reason: "蜜罐字段被填写"}, nil
+	}
+	return Result{Verdict: VerdictHam}, nil
+}
+
+// FormToken 打开评论框时签发的令牌，记录文章 ID 和签发时间
+// 提交时间过短说明是脚本直接提交，令牌过期或缺失同样拒绝
+type FormToken struct {
+	Secret []byte
+	MinAge time.Duration
+	MaxAge time.Duration
+	// 测试时替换当前时间
+	Now func() time.Time
+}
+
+func (f *FormToken) Name() string { return "form_token" }
+
+func (f *FormToken) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+func (f *FormToken) sign(payload string) string {
+	mac := hmac.New(sha256.New, f.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue 为文章签发表单令牌
+func (f *FormToken) Issue(postID uint) string {
+	payload := fmt.Sprintf("%d.%d", postID, f.now().Unix())
+	return payload + "." + f.sign(payload)
+}
+
+func (f *FormToken) Check(_ context.Context, c *Candidate) (Result, error) {
+	reject := func(reason string) (Result, error) {
+		return Result{Verdict: VerdictReject, Reason: reason}, nil
+	}
+
+	parts := strings.Split(c.FormToken, ".")
+	if len(parts) != 3 {
+		return reject("缺少表单令牌")
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(f.sign(payload))) {
+		return reject("表单令牌无效")
+	}
+	if parts[0] != strconv.FormatUint(uint64(c.PostID), 10) {
+		return reject("表单令牌与文章不匹配")
+	}
+	issued, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return reject("表单令牌无效")
+	}
+
+	age := f.now().Sub(time.Unix(issued, 0))
+	if age < f.MinAge {
+		return reject("提交过快")
+	}
+	if f.MaxAge > 0 && age > f.MaxAge {
+		return reject("表单令牌已过期")
+	}
+	return Result{Verdict: VerdictHam}, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// LinkCount 正文中的链接超过 Max 个时判为垃圾评论
+type LinkCount struct {
+	Max int
+}
+
+func (LinkCount) Name() string { return "link_count" }
+
+func (l LinkCount) Check(_ context.Context, c *Candidate) (Result, error) {
+	n := len(linkPattern.FindAllStringIndex(c.Content, -1))
+	if n > l.Max {
+		return Result{Verdict: VerdictSpam, Reason: fmt.Sprintf("包含 %d 个链接", n)}, nil
+	}
+	return Result{Verdict: VerdictHam}, nil
+}
+
+// BlockedWords 昵称、邮箱、网址或正文包含屏蔽词时判为垃圾评论，不区分大小写
+type BlockedWords struct {
+	Words []string
+}
+
+func (BlockedWords) Name() string { return "blocked_words" }
+
+func (b BlockedWords) Check(_ context.Context, c *Candidate) (Result, error) {
+	text := strings.ToLower(strings.Join([]string{c.AuthorName, c.AuthorEmail, c.AuthorWebsite, c.Content}, "\n"))
+	for _, word := range b.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(text, word) {
+			return Result{Verdict: VerdictSpam, Reason: "包含屏蔽词 " + word}, nil
+		}
+	}
+	return Result{Verdict: VerdictHam}, nil
+}
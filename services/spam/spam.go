@@ -0,0 +1,125 @@
+// Package spam 评论入库前的垃圾评论检查链
+package spam
+
+import (
+	"context"
+	"log"
+)
+
+// Verdict 检查结果，数值越大越严重
+type Verdict int
+
+const (
+	VerdictHam      Verdict = iota // 正常评论，进入常规审核
+	VerdictPending                 // 可疑，必须人工审核
+	VerdictSpam                    // 垃圾评论，入库但直接标记为 spam
+	VerdictReject                  // 明显是机器提交，不入库
+	VerdictThrottle                // 提交过于频繁，不入库
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case VerdictHam:
+		return "ham"
+	case VerdictPending:
+		return "pending"
+	case VerdictSpam:
+		return "spam"
+	case VerdictReject:
+		return "reject"
+	case VerdictThrottle:
+		return "throttle"
+	}
+	return "unknown"
+}
+
+// Candidate 待检查的评论
+type Candidate struct {
+	PostID        uint
+	AuthorName    string
+	AuthorEmail   string
+	AuthorWebsite string
+	Content       string
+	IP            string
+	UserAgent     string
+	Referrer      string
+	Permalink     string
+	// 表单中隐藏的蜜罐字段，正常用户不会填写
+	Honeypot string
+	// 打开评论框时获取的表单令牌，用于判断提交耗时
+	FormToken string
+}
+
+// Result 检查链的最终结果
+type Result struct {
+	Verdict Verdict
+	Checker string // 给出该结论的检查器
+	Reason  string
+}
+
+// Checker 单个检查器
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, c *Candidate) (Result, error)
+}
+
+// Learner 可以从审核结果中学习的检查器（本地贝叶斯、远程服务的反馈接口）
+type Learner interface {
+	Learn(ctx context.Context, c *Candidate, spam bool) error
+}
+
+// Unlearner 可以撤销一次学习的检查器，用于审核结论被改判时
+type Unlearner interface {
+	Unlearn(ctx context.Context, c *Candidate, spam bool) error
+}
+
+// Chain 按顺序执行检查器，遇到 spam 及以上的结论立即停止
+type Chain struct {
+	checkers []Checker
+}
+
+func NewChain(checkers ...Checker) *Chain {
+	return &Chain{checkers: checkers}
+}
+
+// Check 返回最严重的结论；检查器出错时记录日志并跳过，不影响评论提交
+func (ch *Chain) Check(ctx context.Context, c *Candidate) Result {
+	final := Result{Verdict: VerdictHam}
+	for _, checker := range ch.checkers {
+		result, err := checker.Check(ctx, c)
+		if err != nil {
+			log.Printf("spam checker %s failed: %v", checker.Name(), err)
+			continue
+		}
+		if result.Verdict <= final.Verdict {
+			continue
+		}
+		result.Checker = checker.Name()
+		final = result
+		if final.Verdict >= VerdictSpam {
+			break
+		}
+	}
+	return final
+}
+
+// Learn 把审核结论反馈给所有 Learner；previous 为上一次反馈的结论，改判时先撤销上一次
+func (ch *Chain) Learn(ctx context.Context, c *Candidate, spam bool, previous *bool) {
+	for _, checker := range ch.checkers {
+		if previous != nil {
+			if *previous == spam {
+				continue
+			}
+			if u, ok := checker.(Unlearner); ok {
+				if err := u.Unlearn(ctx, c, *previous); err != nil {
+					log.Printf("spam checker %s unlearn failed: %v", checker.Name(), err)
+				}
+			}
+		}
+		if l, ok := checker.(Learner); ok {
+			if err := l.Learn(ctx, c, spam); err != nil {
+				log.Printf("spam checker %s learn failed: %v", checker.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package spam
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChain_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	formToken := &FormToken{
+		Secret: []byte("secret"),
+		MinAge: 3 * time.Second,
+		MaxAge: time.Hour,
+		Now:    func() time.Time { return now },
+	}
+	issued := formToken.Issue(1)
+	// 签发后经过 10 秒再提交
+	formToken.Now = func() time.Time { return now.Add(10 * time.Second) }
+
+	chain := NewChain(
+		Honeypot{},
+		formToken,
+		BlockedWords{Words: []string{"Casino"}},
+		LinkCount{Max: 1},
+	)
+
+	tests := []struct {
+		name      string
+		candidate Candidate
+		want      Verdict
+	}{
+		{
+			name:      "Normal comment",
+			candidate: Candidate{PostID: 1, FormToken: issued, Content: "写得很好，参考 https://go.dev"},
+			want:      VerdictHam,
+		},
+		{
+			name:      "Honeypot filled",
+			candidate: Candidate{PostID: 1, FormToken: issued, Honeypot: "http://spam.example"},
+			want:      VerdictReject,
+		},
+		{
+			name:      "Missing form token",
+			candidate: Candidate{PostID: 1, Content: "hello"},
+			want:      VerdictReject,
+		},
+		{
+			name:      "Form token for another post",
+			candidate: Candidate{PostID: 2, FormToken: issued, Content: "hello"},
+			want:      VerdictReject,
+		},
+		{
+			name:      "Tampered form token",
+			candidate: Candidate{PostID: 1, FormToken: issued + "x", Content: "hello"},
+			want:      VerdictReject,
+		},
+		{
+			name:      "Blocked word in author name",
+			candidate: Candidate{PostID: 1, FormToken: issued, AuthorName: "best CASINO", Content: "hello"},
+			want:      VerdictSpam,
+		},
+		{
+			name:      "Too many links",
+			candidate: Candidate{PostID: 1, FormToken: issued, Content: "http://a.example www.b.example"},
+			want:      VerdictSpam,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chain.Check(context.Background(), &tt.candidate); got.Verdict != tt.want {
+				t.Errorf("Check() = %v (%s), want %v", got.Verdict, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormToken_Age(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	f := &FormToken{Secret: []byte("secret"), MinAge: 3 * time.Second, MaxAge: time.Hour, Now: func() time.Time { return now }}
+	issued := f.Issue(1)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    Verdict
+	}{
+		{name: "Submitted too fast", elapsed: time.Second, want: VerdictReject},
+		{name: "Submitted in time", elapsed: time.Minute, want: VerdictHam},
+		{name: "Token expired", elapsed: 2 * time.Hour, want: VerdictReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.Now = func() time.Time { return now.Add(tt.elapsed) }
+			got, _ := f.Check(context.Background(), &Candidate{PostID: 1, FormToken: issued})
+			if got.Verdict != tt.want {
+				t.Errorf("Check() = %v, want %v", got.Verdict, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := &RateLimit{Limit: 2, Window: time.Minute, Now: func() time.Time { return now }}
+	check := func(ip string) Verdict {
+		got, _ := r.Check(context.Background(), &Candidate{IP: ip})
+		return got.Verdict
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := check("1.1.1.1"); got != VerdictHam {
+			t.Fatalf("request %d = %v, want ham", i+1, got)
+		}
+	}
+	if got := check("1.1.1.1"); got != VerdictThrottle {
+		t.Errorf("third request = %v, want throttle", got)
+	}
+	if got := check("2.2.2.2"); got != VerdictHam {
+		t.Errorf("other IP = %v, want ham", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got := check("1.1.1.1"); got != VerdictHam {
+		t.Errorf("after window = %v, want ham", got)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Buy CHEAP pills! 便宜的药 buy")
+	want := []string{"buy", "cheap", "pills", "便宜", "宜的", "的药"}
+	if len(got) != len(want) {
+		t.Fatalf("Tokenize() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Tokenize()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBayes(t *testing.T) {
+	b, err := NewBayes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.MinDocuments = 3
+	ctx := context.Background()
+
+	spamComments := []string{"cheap pills online casino", "casino bonus cheap", "buy cheap pills now"}
+	hamComments := []string{"很喜欢这篇文章的讲解", "goroutine 的例子很清楚", "文章里的例子有个小错误"}
+
+	spamCandidate := &Candidate{Content: "cheap casino pills"}
+	if got, _ := b.Check(ctx, spamCandidate); got.Verdict != VerdictHam {
+		t.Errorf("untrained Check() = %v, want ham", got.Verdict)
+	}
+
+	for _, text := range spamComments {
+		b.Learn(ctx, &Candidate{Content: text}, true)
+	}
+	for _, text := range hamComments {
+		b.Learn(ctx, &Candidate{Content: text}, false)
+	}
+
+	if got, _ := b.Check(ctx, spamCandidate); got.Verdict != VerdictSpam {
+		t.Errorf("Check(spam) = %v, want spam", got.Verdict)
+	}
+	if got, _ := b.Check(ctx, &Candidate{Content: "这篇文章的例子很好"}); got.Verdict != VerdictHam {
+		t.Errorf("Check(ham) = %v, want ham", got.Verdict)
+	}
+
+	// 撤销一篇垃圾评论后样本不足，不再给出判断
+	b.Unlearn(ctx, &Candidate{Content: spamComments[0]}, true)
+	if _, ok := b.Probability(spamCandidate); ok {
+		t.Error("Probability() ok after unlearn, want insufficient training data")
+	}
+}
+
+type fakeChecker struct {
+	verdict Verdict
+	learned []bool
+}
+
+func (f *fakeChecker) Name() string { return "fake" }
+
+func (f *fakeChecker) Check(context.Context, *Candidate) (Result, error) {
+	return Result{Verdict: f.verdict}, nil
+}
+
+func (f *fakeChecker) Learn(_ context.Context, _ *Candidate, spam bool) error {
+	f.learned = append(f.learned, spam)
+	return nil
+}
+
+func TestChain_StopsAtSpam(t *testing.T) {
+	first := &fakeChecker{verdict: VerdictSpam}
+	second := &fakeChecker{verdict: VerdictReject}
+	got := NewChain(first, second).Check(context.Background(), &Candidate{})
+	if got.Verdict != VerdictSpam || got.Checker != "fake" {
+		t.Errorf("Check() = %+v, want spam from first checker", got)
+	}
+
+	wasSpam := true
+	NewChain(first).Learn(context.Background(), &Candidate{}, false, &wasSpam)
+	if len(first.learned) != 1 || first.learned[0] {
+		t.Errorf("Learn() calls = %v, want [false]", first.learned)
+	}
+}
@@ -0,0 +1,54 @@
+package spam
+
+import (
+	"blog-server/db"
+	"blog-server/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBStore 把贝叶斯词频保存在 spam_tokens 表中
+type DBStore struct{}
+
+func (DBStore) Load() (map[string]TokenCount, error) {
+	var rows []models.SpamToken
+	if err := db.GetDB().Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]TokenCount, len(rows))
+	for _, row := range rows {
+		counts[row.Token] = TokenCount{Spam: row.Spam, Ham: row.Ham}
+	}
+	return counts, nil
+}
+
+// Save 在数据库中累加增量，计数不会小于 0
+func (DBStore) Save(delta map[string]TokenCount) error {
+	rows := make([]models.SpamToken, 0, len(delta))
+	tokens := make([]string, 0, len(delta))
+	for token, d := range delta {
+		rows = append(rows, models.SpamToken{Token: token, Spam: d.Spam, Ham: d.Ham})
+		tokens = append(tokens, token)
+	}
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "token"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"spam": gorm.Expr("GREATEST(spam_tokens.spam + EXCLUDED.spam, 0)"),
+				"ham":  gorm.Expr("GREATEST(spam_tokens.ham + EXCLUDED.ham, 0)"),
+			}),
+		}).Create(&rows).Error; err != nil {
+			return err
+		}
+		// 撤销学习时新插入的词计数为负，归零
+		return tx.Model(&models.SpamToken{}).
+			Where("token IN ? AND (spam < 0 OR ham < 0)", tokens).
+			Updates(map[string]any{
+				"spam": gorm.Expr("GREATEST(spam, 0)"),
+				"ham":  gorm.Expr("GREATEST(ham, 0)"),
+			}).Error
+	})
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-server/config"
+	"blog-server/db"
+	"blog-server/forms"
+	"blog-server/models"
+	"blog-server/services/searchquery"
+	"blog-server/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultSuggestLimit   = 5
+	suggestQueryCandidate = 500 // 参与联想的热门搜索词数量
+	maxSearchQueryLength  = 100
+
+	defaultSuggestMinQueryCount = 3
+)
+
+// suggestEntry 联想候选项，预先计算好小写文本、全拼和首字母
+type suggestEntry struct {
+	ID       uint
+	Text     string
+	Slug     string
+	Weight   int64 // 标签的文章数、搜索词的搜索次数
+	lower    string
+	pinyin   string
+	initials string
+}
+
+func newSuggestEntry(id uint, text, slug string, weight int64) suggestEntry {
+	full, initials := utils.PinyinKeys(text)
+	return suggestEntry{
+		ID:       id,
+		Text:     text,
+		Slug:     slug,
+		Weight:   weight,
+		lower:    strings.ToLower(text),
+		pinyin:   full,
+		initials: initials,
+	}
+}
+
+// match 返回匹配程度，0 表示不匹配：文本前缀 > 全拼/首字母前缀 > 文本包含
+func (e suggestEntry) match(query, key string) int {
+	switch {
+	case strings.HasPrefix(e.lower, query):
+		return 3
+	case key != "" && (strings.HasPrefix(e.pinyin, key) || strings.HasPrefix(e.initials, key)):
+		return 2
+	case strings.Contains(e.lower, query):
+		return 1
+	}
+	return 0
+}
+
+// suggestIndex 已发布文章标题、标签和热门搜索词的内存索引，用于拼音匹配
+type suggestIndex struct {
+	posts   []suggestEntry
+	tags    []suggestEntry
+	queries []suggestEntry
+}
+
+var suggestState struct {
+	sync.Mutex
+	index    *suggestIndex
+	builtAt  time.Time
+	building bool
+}
+
+// getSuggestIndex 返回联想索引，首次调用时同步构建，过期后在后台刷新，刷新期间继续使用旧索引
+func getSuggestIndex() (*suggestIndex, error) {
+	interval := config.GetConfig().GetDuration("search.suggest.refreshInterval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	suggestState.Lock()
+	index := suggestState.index
+	if index != nil && time.Since(suggestState.builtAt) > interval && !suggestState.building {
+		suggestState.building = true
+		go func() {
+			if _, err := rebuildSuggestIndex(); err != nil {
+				log.Printf("failed to refresh suggest index: %v", err)
+			}
+		}()
+	}
+	suggestState.Unlock()
+
+	if index != nil {
+		return index, nil
+	}
+	return rebuildSuggestIndex()
+}
+
+func rebuildSuggestIndex() (*suggestIndex, error) {
+	index, err := buildSuggestIndex()
+
+	suggestState.Lock()
+	defer suggestState.Unlock()
+	suggestState.building = false
+	if err != nil {
+		return nil, err
+	}
+	suggestState.index = index
+	suggestState.builtAt = time.Now()
+	return index, nil
+}
+
+func buildSuggestIndex() (*suggestIndex, error) {
+	var posts []models.Post
+	if err := db.GetDB().Scopes(PublishedPosts).Select("id, title, slug").Order("adjust_time DESC").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	tags, err := ListTagCounts(true)
+	if err != nil {
+		return nil, err
+	}
+	// 搜索次数太少的词不推荐，避免个别访客的输入直接出现在所有人的联想中
+	minCount := config.GetConfig().GetInt64("search.suggest.minQueryCount")
+	if minCount <= 0 {
+		minCount = defaultSuggestMinQueryCount
+	}
+	var queries []models.SearchQuery
+	if err := db.GetDB().Where("count >= ?", minCount).Order("count DESC").Limit(suggestQueryCandidate).Find(&queries).Error; err != nil {
+		return nil, err
+	}
+
+	index := &suggestIndex{}
+	for _, post := range posts {
+		index.posts = append(index.posts, newSuggestEntry(post.ID, post.Title, post.Slug, 0))
+	}
+	for _, tag := range tags {
+		if tag.Count > 0 {
+			index.tags = append(index.tags, newSuggestEntry(tag.ID, tag.Name, tag.Slug, tag.Count))
+		}
+	}
+	for _, query := range queries {
+		index.queries = append(index.queries, newSuggestEntry(query.ID, query.Query, "", query.Count))
+	}
+	return index, nil
+}
+
+// matchSuggestEntries 返回匹配的候选项，按匹配程度和权重排序，skip 中的 ID 会被跳过
+func matchSuggestEntries(entries []suggestEntry, query, key string, limit int, skip map[uint]bool) []suggestEntry {
+	type scored struct {
+		entry suggestEntry
+		rank  int
+	}
+	var matched []scored
+	for _, e := range entries {
+		if skip[e.ID] {
+			continue
+		}
+		if rank := e.match(query, key); rank > 0 {
+			matched = append(matched, scored{e, rank})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].rank != matched[j].rank {
+			return matched[i].rank > matched[j].rank
+		}
+		return matched[i].entry.Weight > matched[j].entry.Weight
+	})
+
+	result := make([]suggestEntry, 0, min(len(matched), limit))
+	for i := 0; i < len(matched) && i < limit; i++ {
+		result = append(result, matched[i].entry)
+	}
+	return result
+}
+
+// prefixTSQuery 把分词结果转为前缀匹配的 tsquery，如 "go:* & 并发:*"，去掉 tsquery 的运算符
+func prefixTSQuery(words []string) string {
+	var parts []string
+	for _, word := range words {
+		if word = searchquery.Lexeme(word); word != "" {
+			parts = append(parts, word+":*")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// normalizeSearchQuery 合并连续空白并转为小写，超长时截断
+func normalizeSearchQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if runes := []rune(query); len(runes) > maxSearchQueryLength {
+		query = strings.TrimSpace(string(runes[:maxSearchQueryLength]))
+	}
+	return query
+}
+
+// Suggest 返回输入联想：标题前缀全文匹配的文章，以及按文本、全拼、拼音首字母匹配的文章、标签和热门搜索
+// 全文匹配受 search.suggest.timeout 限制，超时后只返回内存索引的匹配结果
+func Suggest(q string, limit int) (*forms.SuggestResponse, error) {
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	resp := &forms.SuggestResponse{
+		Posts:   []forms.SuggestPost{},
+		Tags:    []forms.SuggestTag{},
+		Queries: []string{},
+	}
+
+	query := normalizeSearchQuery(q)
+	if query == "" {
+		return resp, nil
+	}
+	key, _ := utils.PinyinKeys(query)
+
+	seen := make(map[uint]bool)
+	if tsQuery := prefixTSQuery(SegmentText(query)); tsQuery != "" {
+		timeout := config.GetConfig().GetDuration("search.suggest.timeout")
+		if timeout <= 0 {
+			timeout = 300 * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var posts []models.Post
+		err := db.GetDB().WithContext(ctx).Scopes(PublishedPosts).
+			Select("id, title, slug").
+			Where("tokens @@ to_tsquery('simple', ?)", tsQuery).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "ts_rank(tokens, to_tsquery('simple', ?)) DESC",
+				Vars:               []any{tsQuery},
+				WithoutParentheses: true,
+			}}).
+			Limit(limit).
+			Find(&posts).Error
+		if err != nil {
+			log.Printf("suggest prefix query failed: %v", err)
+		}
+		for _, post := range posts {
+			seen[post.ID] = true
+			resp.Posts = append(resp.Posts, forms.SuggestPost{ID: post.ID, Title: post.Title, Slug: post.Slug})
+		}
+	}
+
+	index, err := getSuggestIndex()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range matchSuggestEntries(index.posts, query, key, limit-len(resp.Posts), seen) {
+		resp.Posts = append(resp.Posts, forms.SuggestPost{ID: e.ID, Title: e.Text, Slug: e.Slug})
+	}
+	for _, e := range matchSuggestEntries(index.tags, query, key, limit, nil) {
+		resp.Tags = append(resp.Tags, forms.SuggestTag{Name: e.Text, Slug: e.Slug, Count: e.Weight})
+	}
+	for _, e := range matchSuggestEntries(index.queries, query, key, limit, nil) {
+		if e.Text != query {
+			resp.Queries = append(resp.Queries, e.Text)
+		}
+	}
+	return resp, nil
+}
+
+// recordSearchQuery 记录一次有结果的搜索，用于热门搜索建议，q 为去掉查询语法后的普通搜索词
+func recordSearchQuery(q string) {
+	query := normalizeSearchQuery(q)
+	if query == "" {
+		return
+	}
+
+	now := time.Now()
+	err := db.GetDB().Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "query"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"count":            gorm.Expr("search_queries.count + 1"),
+			"last_searched_at": now,
+		}),
+	}).Create(&models.SearchQuery{Query: query, Count: 1, LastSearchedAt: now}).Error
+	if err != nil {
+		log.Printf("failed to record search query %q: %v", query, err)
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"blog-server/db"
+	"blog-server/models"
+	"blog-server/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTagNotFound    = errors.New("标签不存在")
+	ErrTagExists      = errors.New("已存在同名标签，请使用合并")
+	ErrTagInUse       = errors.New("标签仍被文章使用，请先合并或移除")
+	ErrTagNameEmpty   = errors.New("标签名不能为空")
+	ErrTagMergeTarget = errors.New("合并目标不能同时是被合并的标签")
+)
+
+// 标签名无法生成 slug 时使用的默认前缀
+const defaultTagSlug = "tag"
+
+// GenerateTagSlug 根据手动指定的 slug 或标签名生成唯一的拼音 slug
+// excludeID 为当前标签 ID，新建标签传 0
+func GenerateTagSlug(tx *gorm.DB, name, requested string, excludeID uint) (string, error) {
+	base := tagSlugBase(name, requested)
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		err := tx.Model(&models.Tag{}).
+			Where("slug = ? AND id <> ?", slug, excludeID).
+			Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func tagSlugBase(name, requested string) string {
+	base := utils.Slugify(requested)
+	if base == "" {
+		base = utils.Slugify(name)
+	}
+	if base == "" {
+		base = defaultTagSlug
+	}
+	return base
+}
+
+// TagUpdate 修改标签的参数，为 nil 的字段保持不变
+type TagUpdate struct {
+	Name        *string
+	Slug        *string
+	Description *string
+	Color       *string
+}
+
+// UpdateTag 重命名标签或修改描述、颜色、slug
+// 新名称与其他标签重复时返回 ErrTagExists；重命名后重新生成相关文章的索引
+func UpdateTag(id uint, input TagUpdate) (*models.Tag, error) {
+	var tag models.Tag
+	renamed := false
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tag, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTagNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if input.Name != nil {
+			name := utils.NormalizeTagName(*input.Name)
+			if name == "" {
+				return ErrTagNameEmpty
+			}
+			key := utils.TagNameKey(name)
+
+			var count int64
+			if err := tx.Model(&models.Tag{}).Where("name_key = ? AND id <> ?", key, tag.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrTagExists
+			}
+			renamed = tag.Name != name
+			tag.Name = name
+			tag.NameKey = key
+		}
+		// slug 用于标签页地址，重命名时不自动修改，避免链接失效
+		if input.Slug != nil {
+			slug, err := GenerateTagSlug(tx, tag.Name, *input.Slug, tag.ID)
+			if err != nil {
+				return err
+			}
+			tag.Slug = slug
+		}
+		if input.Description != nil {
+			tag.Description = *input.Description
+		}
+		if input.Color != nil {
+			tag.Color = *input.Color
+		}
+		return tx.Save(&tag).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if renamed {
+		reindexTagPosts([]uint{tag.ID})
+	}
+	return &tag, nil
+}
+
+// MergeTags 把 sourceIDs 合并到 targetID：文章改为关联目标标签，被合并的标签随后删除
+// 返回受影响的文章数
+func MergeTags(targetID uint, sourceIDs []uint) (int, error) {
+	seen := make(map[uint]bool, len(sourceIDs))
+	unique := sourceIDs[:0:0]
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return 0, ErrTagMergeTarget
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sourceIDs = unique
+
+	var postIDs []uint
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Tag{}).Where("id IN ?", append([]uint{targetID}, sourceIDs...)).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(sourceIDs)+1) {
+			return ErrTagNotFound
+		}
+
+		if err := tx.Model(&models.PostTag{}).
+			Where("tag_id IN ?", sourceIDs).
+			Distinct().
+			Pluck("post_id", &postIDs).Error; err != nil {
+			return err
+		}
+
+		// 已经有目标标签的文章保留原位置，否则使用被合并标签中最靠前的位置
+		sql := `
+			INSERT INTO post_tags (post_id, tag_id, position)
+			SELECT post_id, ?, MIN(position)
+			FROM post_tags
+			WHERE tag_id IN ?
+			GROUP BY post_id
+			ON CONFLICT DO NOTHING
+		`
+		if err := tx.Exec(sql, targetID, sourceIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id IN ?", sourceIDs).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		// 物理删除，否则软删除的记录会占用唯一的标签名
+		return tx.Unscoped().Where("id IN ?", sourceIDs).Delete(&models.Tag{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	reindexPosts(postIDs)
+	return len(postIDs), nil
+}
+
+// DeleteTag 删除没有文章使用的标签
+func DeleteTag(id uint) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.PostTag{}).Where("tag_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTagInUse
+		}
+
+		result := tx.Unscoped().Delete(&models.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTagNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteUnusedTags 删除所有没有文章使用的标签，返回删除的数量
+func DeleteUnusedTags() (int64, error) {
+	result := db.GetDB().Unscoped().
+		Where("NOT EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = tags.id)").
+		Delete(&models.Tag{})
+	return result.RowsAffected, result.Error
+}
+
+// reindexTagPosts 重新生成使用了这些标签的文章的搜索索引
+func reindexTagPosts(tagIDs []uint) {
+	var postIDs []uint
+	if err := db.GetDB().Model(&models.PostTag{}).
+		Where("tag_id IN ?", tagIDs).
+		Distinct().
+		Pluck("post_id", &postIDs).Error; err != nil {
+		log.Printf("failed to load posts for tags %v: %v", tagIDs, err)
+		return
+	}
+	reindexPosts(postIDs)
+}
+
+// reindexPosts 重新生成文章的搜索索引，单篇失败只记录日志
+func reindexPosts(postIDs []uint) {
+	if len(postIDs) == 0 {
+		return
+	}
+
+	var posts []models.Post
+	if err := db.GetDB().Unscoped().Where("id IN ?", postIDs).Find(&posts).Error; err != nil {
+		log.Printf("failed to load posts for reindex: %v", err)
+		return
+	}
+	for _, post := range posts {
+		if err := UpdatePostTokens(db.GetDB(), &post); err != nil {
+			log.Printf("failed to update tokens for post ID %d: %v", post.ID, err)
+		}
+	}
+}
+
+// NormalizeTags 为老标签补齐 name_key 和 slug，并合并规范化后重名的标签（保留 ID 最小的）
+func NormalizeTags() error {
+	// 标签现在是硬删除；旧版本软删除的标签仍占用 name 唯一索引，会让 ResolveTagIDs 的批量插入被跳过
+	var deleted []uint
+	if err := db.GetDB().Unscoped().Model(&models.Tag{}).Where("deleted_at IS NOT NULL").Pluck("id", &deleted).Error; err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("tag_id IN ?", deleted).Delete(&models.PostTag{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&models.Tag{}, deleted).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to purge soft-deleted tags: %w", err)
+		}
+	}
+
+	var tags []models.Tag
+	if err := db.GetDB().Order("id ASC").Find(&tags).Error; err != nil {
+		return err
+	}
+
+	keep := make(map[string]uint)
+	duplicates := make(map[uint][]uint)
+	for _, tag := range tags {
+		key := utils.TagNameKey(tag.Name)
+		if targetID, ok := keep[key]; ok {
+			duplicates[targetID] = append(duplicates[targetID], tag.ID)
+			continue
+		}
+		keep[key] = tag.ID
+	}
+
+	for targetID, sourceIDs := range duplicates {
+		if _, err := MergeTags(targetID, sourceIDs); err != nil {
+			return fmt.Errorf("failed to merge duplicate tags into %d: %w", targetID, err)
+		}
+	}
+
+	updated := 0
+	for _, tag := range tags {
+		key := utils.TagNameKey(tag.Name)
+		if keep[key] != tag.ID || (tag.NameKey == key && tag.Slug != "") {
+			continue
+		}
+
+		err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+			updates := map[string]any{"name_key": key}
+			if tag.Slug == "" {
+				slug, err := GenerateTagSlug(tx, tag.Name, "", tag.ID)
+				if err != nil {
+					return err
+				}
+				updates["slug"] = slug
+			}
+			return tx.Model(&tag).UpdateColumns(updates).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to normalize tag ID %d: %w", tag.ID, err)
+		}
+		updated++
+	}
+
+	if updated > 0 || len(duplicates) > 0 || len(deleted) > 0 {
+		utils.Log("Tags normalized: ", updated, ", merged groups: ", len(duplicates), ", purged: ", len(deleted))
+	}
+	return nil
+}
@@ -1,30 +1,260 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"blog-server/db"
 	"blog-server/models"
+	"blog-server/utils"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
-// ResolveTagIDs 根据标签名数组查询/创建标签，并返回对应的 ID 数组
-func ResolveTagIDs(tagNames []string) ([]int64, error) {
-	var tagIDs []int64
+const (
+	pgUniqueViolation    = "23505"
+	maxTagInsertAttempts = 3
+)
 
+// 标签筛选模式
+const (
+	TagMatchAny = "any" // 包含任意一个标签
+	TagMatchAll = "all" // 包含全部标签
+)
+
+// ResolveTagIDs 根据标签名数组查询/创建标签，并按传入顺序返回对应的 ID 数组
+// 标签名按 utils.TagNameKey 匹配已有标签，缺失的标签用一条 INSERT ... ON CONFLICT (name_key) DO NOTHING 批量创建，
+// 新标签保存规范化后的名称
+func ResolveTagIDs(tagNames []string) ([]int64, error) {
+	var names, keys []string
+	seen := make(map[string]bool, len(tagNames))
 	for _, tagName := range tagNames {
-		var tag models.Tag
-		if err := db.DB.Where("name = ?", tagName).First(&tag).Error; err != nil {
-			// 标签不存在就新建
-			tag = models.Tag{Name: tagName}
-			if err := db.DB.Create(&tag).Error; err != nil {
-				return nil, err
-			}
+		name := utils.NormalizeTagName(tagName)
+		key := utils.TagNameKey(name)
+		if key == "" || seen[key] {
+			continue
 		}
-		tagIDs = append(tagIDs, int64(tag.ID))
+		seen[key] = true
+		names = append(names, name)
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, nil
 	}
 
+	ids := make(map[string]int64, len(keys))
+	// 并发请求可能抢先创建同名标签或占用分配的 slug，此时重新查询并分配 slug 后重试
+	for attempt := 1; ; attempt++ {
+		missingNames, missingKeys, err := lookupMissingTags(names, keys, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(missingKeys) == 0 {
+			break
+		}
+		err = insertTags(missingNames, missingKeys, ids)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && attempt < maxTagInsertAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tagIDs := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		tagIDs = append(tagIDs, ids[key])
+	}
 	return tagIDs, nil
 }
 
-// GetTagNamesByIDs 根据 tagID 列表返回对应的 tagName
+type tagKeyRow struct {
+	ID      int64
+	NameKey string
+}
+
+// lookupMissingTags 查询 ids 中还没有的标签并写入 ids，返回仍不存在的标签名和 name_key
+func lookupMissingTags(names, keys []string, ids map[string]int64) ([]string, []string, error) {
+	var lookup []string
+	for _, key := range keys {
+		if _, ok := ids[key]; !ok {
+			lookup = append(lookup, key)
+		}
+	}
+	if len(lookup) == 0 {
+		return nil, nil, nil
+	}
+
+	var rows []tagKeyRow
+	if err := db.GetDB().Model(&models.Tag{}).Select("id, name_key").Where("name_key IN ?", lookup).Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rows {
+		ids[row.NameKey] = row.ID
+	}
+
+	var missingNames, missingKeys []string
+	for i, key := range keys {
+		if _, ok := ids[key]; !ok {
+			missingNames = append(missingNames, names[i])
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	return missingNames, missingKeys, nil
+}
+
+// insertTags 批量创建标签并把新 ID 写入 ids
+// 只忽略 name_key 冲突（被跳过的行由调用方重新查询），slug 等其他唯一索引冲突会返回错误
+func insertTags(names, keys []string, ids map[string]int64) error {
+	slugs, err := allocateTagSlugs(names)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var rows []tagKeyRow
+	// 冲突目标与 db.EnsureTagIndexes 中的 idx_tags_name_key 一致
+	err = db.GetDB().Raw(`
+		INSERT INTO tags (name, name_key, slug, created_at, updated_at)
+		SELECT name, name_key, slug, ?, ? FROM unnest(?::text[], ?::text[], ?::text[]) AS t(name, name_key, slug)
+		ON CONFLICT (name_key) WHERE name_key <> '' AND deleted_at IS NULL DO NOTHING
+		RETURNING id, name_key
+	`, now, now, pq.Array(names), pq.Array(keys), pq.Array(slugs)).Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ids[row.NameKey] = row.ID
+	}
+	return nil
+}
+
+// allocateTagSlugs 为一批新标签分配互不重复的 slug，规则与 GenerateTagSlug 相同
+func allocateTagSlugs(names []string) ([]string, error) {
+	bases := make([]string, len(names))
+	patterns := make([]string, len(names))
+	for i, name := range names {
+		bases[i] = tagSlugBase(name, "")
+		patterns[i] = bases[i] + "-%"
+	}
+
+	var existing []string
+	err := db.GetDB().Model(&models.Tag{}).
+		Where("slug IN ? OR slug LIKE ANY (?)", bases, pq.Array(patterns)).
+		Pluck("slug", &existing).Error
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing)+len(names))
+	for _, slug := range existing {
+		taken[slug] = true
+	}
+
+	slugs := make([]string, len(names))
+	for i, base := range bases {
+		slug := base
+		for n := 2; taken[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[slug] = true
+		slugs[i] = slug
+	}
+	return slugs, nil
+}
+
+// SetPostTags 用 tagIDs 替换文章的标签，保留传入顺序，重复或已删除的标签会被忽略
+func SetPostTags(tx *gorm.DB, postID uint, tagIDs []int64) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	// 历史版本中的标签可能已被合并或删除
+	var existing []int64
+	if err := tx.Model(&models.Tag{}).Where("id IN ?", tagIDs).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	exists := make(map[int64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	seen := make(map[int64]bool, len(tagIDs))
+	rows := make([]models.PostTag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if seen[id] || !exists[id] {
+			continue
+		}
+		seen[id] = true
+		rows = append(rows, models.PostTag{PostID: postID, TagID: uint(id), Position: len(rows)})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// GetPostTagIDs 按顺序返回文章的标签 ID
+func GetPostTagIDs(tx *gorm.DB, postID uint) ([]int64, error) {
+	var ids []int64
+	err := tx.Model(&models.PostTag{}).
+		Where("post_id = ?", postID).
+		Order("position ASC").
+		Pluck("tag_id", &ids).Error
+	return ids, err
+}
+
+// GetPostTagNames 按顺序返回文章的标签名
+func GetPostTagNames(postID uint) ([]string, error) {
+	tagNames, err := GetTagNamesByPostIDs([]uint{postID})
+	if err != nil {
+		return nil, err
+	}
+	return tagNames[postID], nil
+}
+
+// GetTagNamesByPostIDs 一次查询返回一页文章的标签名，每篇文章的标签按 position 排序
+// 结果包含所有传入的文章 ID，没有标签的文章对应空数组
+func GetTagNamesByPostIDs(postIDs []uint) (map[uint][]string, error) {
+	return tagNamesByPostIDs(db.GetDB(), postIDs)
+}
+
+func tagNamesByPostIDs(tx *gorm.DB, postIDs []uint) (map[uint][]string, error) {
+	tagNames := make(map[uint][]string, len(postIDs))
+	for _, id := range postIDs {
+		tagNames[id] = []string{}
+	}
+	if len(postIDs) == 0 {
+		return tagNames, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Name   string
+	}
+	err := tx.Table("post_tags AS pt").
+		Select("pt.post_id, t.name").
+		Joins("JOIN tags t ON t.id = pt.tag_id AND t.deleted_at IS NULL").
+		Where("pt.post_id IN ?", postIDs).
+		Order("pt.post_id ASC, pt.position ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tagNames[row.PostID] = append(tagNames[row.PostID], row.Name)
+	}
+	return tagNames, nil
+}
+
+// GetTagNamesByIDs 根据 tagID 列表返回对应的 tagName，保持传入顺序，用于历史版本
 func GetTagNamesByIDs(tagIDs []int64) ([]string, error) {
 	if len(tagIDs) == 0 {
 		return []string{}, nil
@@ -35,10 +265,87 @@ func GetTagNamesByIDs(tagIDs []int64) ([]string, error) {
 		return nil, err
 	}
 
-	var names []string
+	byID := make(map[int64]string, len(tags))
 	for _, tag := range tags {
-		names = append(names, tag.Name)
+		byID[int64(tag.ID)] = tag.Name
+	}
+	names := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if name, ok := byID[id]; ok {
+			names = append(names, name)
+		}
 	}
-
 	return names, nil
 }
+
+// normalizeTagFilter 支持重复参数 tags=a&tags=b 以及逗号分隔 tags=a,b，返回去重后的 utils.TagNameKey
+func normalizeTagFilter(names []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			part = utils.TagNameKey(part)
+			if part != "" && !seen[part] {
+				seen[part] = true
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// TagFilterSQL 生成按标签筛选文章的条件，postIDColumn 为外层查询中文章 ID 的列名
+// 没有标签时返回空字符串
+func TagFilterSQL(postIDColumn string, names []string, mode string) (string, []any) {
+	names = normalizeTagFilter(names)
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	sub := `SELECT pt.post_id FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id AND t.deleted_at IS NULL
+		WHERE t.name_key IN ?`
+	args := []any{names}
+	if mode == TagMatchAll {
+		sub += " GROUP BY pt.post_id HAVING COUNT(DISTINCT t.id) = ?"
+		args = append(args, len(names))
+	}
+	return postIDColumn + " IN (" + sub + ")", args
+}
+
+// PostsWithTags 按标签筛选文章的 scope
+func PostsWithTags(names []string, mode string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if sql, args := TagFilterSQL("posts.id", names, mode); sql != "" {
+			return tx.Where(sql, args...)
+		}
+		return tx
+	}
+}
+
+// TagCount 标签及其下的文章数
+type TagCount struct {
+	models.Tag
+	Count int64
+}
+
+// ListTagCounts 返回所有标签和文章数，按文章数倒序；publishedOnly 为 true 时只统计已发布文章
+func ListTagCounts(publishedOnly bool) ([]TagCount, error) {
+	postJoin := "LEFT JOIN posts p ON p.id = pt.post_id AND p.deleted_at IS NULL"
+	var args []any
+	if publishedOnly {
+		postJoin += " AND p.status = ?"
+		args = append(args, models.PostStatusPublished)
+	}
+
+	var rows []TagCount
+	err := db.GetDB().Table("tags AS t").
+		Select("t.*, COUNT(p.id) AS count").
+		Joins("LEFT JOIN post_tags pt ON pt.tag_id = t.id").
+		Joins(postJoin, args...).
+		Where("t.deleted_at IS NULL").
+		Group("t.id").
+		Order("count DESC, t.name ASC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,291 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"blog-server/config"
+	"blog-server/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 密钥状态
+const (
+	KeyActive  = "active"  // 用于签发和校验，有且只有一个
+	KeyVerify  = "verify"  // 只用于校验：轮换中的旧密钥，或即将启用的新密钥
+	KeyRetired = "retired" // 已停用，使用该密钥签发的 token 一律拒绝
+)
+
+// 未配置 jwt.keys 时使用 server.jwtKey 作为唯一密钥
+const defaultKeyID = "default"
+
+var (
+	ErrUnknownKey = errors.New("未知的签名密钥")
+	ErrRetiredKey = errors.New("签名密钥已停用")
+)
+
+// KeyConfig 对应配置文件中 jwt.keys 的一项
+type KeyConfig struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"alg"` // HS256 或 EdDSA
+	Status         string `mapstructure:"status"`
+	Secret         string `mapstructure:"secret"`         // HS256 密钥
+	SecretEnv      string `mapstructure:"secretEnv"`      // 从环境变量读取 HS256 密钥
+	PrivateKeyPath string `mapstructure:"privateKeyPath"` // EdDSA PKCS#8 私钥
+	PublicKeyPath  string `mapstructure:"publicKeyPath"`  // 只校验的 EdDSA 密钥可以只提供公钥
+}
+
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	status     string
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// signingKey 签名用的密钥
+func (k *key) signingKey() any {
+	if k.method == jwt.SigningMethodEdDSA {
+		return k.privateKey
+	}
+	return k.secret
+}
+
+// verificationKey 校验用的密钥
+func (k *key) verificationKey() any {
+	if k.method == jwt.SigningMethodEdDSA {
+		return k.publicKey
+	}
+	return k.secret
+}
+
+// KeySet 按 kid 管理的一组签名密钥
+type KeySet struct {
+	keys   map[string]*key
+	active *key
+}
+
+// NewKeySet 根据配置创建密钥集合，要求有且只有一个 active 密钥
+func NewKeySet(configs []KeyConfig) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*key, len(configs))}
+
+	for _, cfg := range configs {
+		k, err := loadKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("加载密钥 %q 失败: %w", cfg.ID, err)
+		}
+		if _, exists := set.keys[k.id]; exists {
+			return nil, fmt.Errorf("密钥 %q 重复", k.id)
+		}
+		set.keys[k.id] = k
+
+		if k.status == KeyActive {
+			if set.active != nil {
+				return nil, errors.New("只能有一个 active 密钥")
+			}
+			set.active = k
+		}
+	}
+
+	if set.active == nil {
+		return nil, errors.New("缺少 active 密钥")
+	}
+	if set.active.method == jwt.SigningMethodEdDSA && set.active.privateKey == nil {
+		return nil, fmt.Errorf("active 密钥 %q 缺少私钥", set.active.id)
+	}
+	return set, nil
+}
+
+func loadKey(cfg KeyConfig) (*key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("kid 不能为空")
+	}
+
+	k := &key{id: cfg.ID, status: cfg.Status}
+	switch k.status {
+	case KeyActive, KeyVerify, KeyRetired:
+	case "":
+		k.status = KeyVerify
+	default:
+		return nil, fmt.Errorf("未知的密钥状态 %q", cfg.Status)
+	}
+
+	switch cfg.Algorithm {
+	case "", "HS256":
+		k.method = jwt.SigningMethodHS256
+		secret := cfg.Secret
+		if cfg.SecretEnv != "" {
+			secret = os.Getenv(cfg.SecretEnv)
+		}
+		if secret == "" {
+			return nil, errors.New("HS256 密钥不能为空")
+		}
+		k.secret = []byte(secret)
+
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+		if cfg.PrivateKeyPath != "" {
+			pemBytes, err := os.ReadFile(cfg.PrivateKeyPath)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := utils.ParseEd25519PrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			k.privateKey = priv
+			k.publicKey = priv.Public().(ed25519.PublicKey)
+		} else if cfg.PublicKeyPath != "" {
+			pemBytes, err := os.ReadFile(cfg.PublicKeyPath)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := utils.ParseEd25519PublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			k.publicKey = pub
+		} else {
+			return nil, errors.New("EdDSA 密钥需要 privateKeyPath 或 publicKeyPath")
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的算法 %q", cfg.Algorithm)
+	}
+
+	return k, nil
+}
+
+// Sign 使用 active 密钥签名，并在 header 中写入 kid
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(s.active.method, claims)
+	t.Header["kid"] = s.active.id
+	return t.SignedString(s.active.signingKey())
+}
+
+// Parse 根据 header 中的 kid 选择密钥校验 token，已停用的密钥签发的 token 会被拒绝
+func (s *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, ok := s.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if k.status == KeyRetired {
+			return nil, ErrRetiredKey
+		}
+		// 防止算法混淆：token 声明的算法必须与密钥一致
+		if t.Method.Alg() != k.method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return k.verificationKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWK 公钥的 JSON Web Key 表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWKS 返回所有未停用的非对称公钥，HS256 密钥不会公开
+func (s *KeySet) JWKS() []JWK {
+	jwks := []JWK{}
+	for _, k := range s.keys {
+		if k.method != jwt.SigningMethodEdDSA || k.status == KeyRetired {
+			continue
+		}
+		jwks = append(jwks, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.publicKey),
+			Kid: k.id,
+			Alg: k.method.Alg(),
+			Use: "sig",
+		})
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+var (
+	defaultKeySet *KeySet
+	loadErr       error
+	loadOnce      sync.Once
+)
+
+// keys 首次使用时从配置加载密钥集合
+func keys() (*KeySet, error) {
+	loadOnce.Do(func() {
+		defaultKeySet, loadErr = LoadKeySet()
+	})
+	return defaultKeySet, loadErr
+}
+
+// Init 启动时加载密钥，配置有误时尽早失败
+func Init() error {
+	_, err := keys()
+	return err
+}
+
+// LoadKeySet 从 jwt.keys 加载密钥，未配置时退回使用 server.jwtKey
+func LoadKeySet() (*KeySet, error) {
+	cfg := config.GetConfig()
+
+	var configs []KeyConfig
+	if err := cfg.UnmarshalKey("jwt.keys", &configs); err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		configs = []KeyConfig{{
+			ID:        defaultKeyID,
+			Algorithm: "HS256",
+			Status:    KeyActive,
+			Secret:    cfg.GetString("server.jwtKey"),
+		}}
+	}
+	return NewKeySet(configs)
+}
+
+// Sign 使用配置的 active 密钥签发 token
+func Sign(claims jwt.Claims) (string, error) {
+	set, err := keys()
+	if err != nil {
+		return "", err
+	}
+	return set.Sign(claims)
+}
+
+// Parse 校验并解析 token
+func Parse(tokenString string) (jwt.MapClaims, error) {
+	set, err := keys()
+	if err != nil {
+		return nil, err
+	}
+	return set.Parse(tokenString)
+}
+
+// PublicJWKS 返回配置中的公钥集合
+func PublicJWKS() ([]JWK, error) {
+	set, err := keys()
+	if err != nil {
+		return nil, err
+	}
+	return set.JWKS(), nil
+}
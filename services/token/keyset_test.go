@@ -0,0 +1,132 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeEd25519Key(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ed25519.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	edPath := writeEd25519Key(t)
+
+	oldSet, err := NewKeySet([]KeyConfig{
+		{ID: "hs-old", Algorithm: "HS256", Secret: "old-secret", Status: KeyActive},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := oldSet.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newSet, err := NewKeySet([]KeyConfig{
+		{ID: "ed-new", Algorithm: "EdDSA", PrivateKeyPath: edPath, Status: KeyActive},
+		{ID: "hs-old", Algorithm: "HS256", Secret: "old-secret", Status: KeyVerify},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := newSet.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retiredSet, err := NewKeySet([]KeyConfig{
+		{ID: "ed-new", Algorithm: "EdDSA", PrivateKeyPath: edPath, Status: KeyActive},
+		{ID: "hs-old", Algorithm: "HS256", Secret: "old-secret", Status: KeyRetired},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		set     *KeySet
+		token   string
+		wantErr bool
+	}{
+		{name: "EdDSA token signed with active key", set: newSet, token: newToken},
+		{name: "HS256 token signed with verify-only key", set: newSet, token: oldToken},
+		{name: "Token signed with retired key", set: retiredSet, token: oldToken, wantErr: true},
+		{name: "Token signed with unknown key", set: oldSet, token: newToken, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.set.Parse(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims["sub"] != "user-1" {
+				t.Errorf("Parse() sub = %v, want user-1", claims["sub"])
+			}
+		})
+	}
+
+	if jwks := newSet.JWKS(); len(jwks) != 1 || jwks[0].Kid != "ed-new" {
+		t.Errorf("JWKS() = %v, want only ed-new", jwks)
+	}
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	set, err := NewKeySet([]KeyConfig{
+		{ID: "k1", Algorithm: "EdDSA", PrivateKeyPath: writeEd25519Key(t), Status: KeyActive},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 用公钥当作 HMAC 密钥伪造的 token 必须被拒绝
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "k1"
+	signed, err := forged.SignedString([]byte(set.keys["k1"].publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := set.Parse(signed); err == nil {
+		t.Error("Parse() accepted a token whose alg does not match the key")
+	}
+}
+
+func TestNewKeySet_RequiresSingleActiveKey(t *testing.T) {
+	_, err := NewKeySet([]KeyConfig{
+		{ID: "a", Secret: "a", Status: KeyActive},
+		{ID: "b", Secret: "b", Status: KeyActive},
+	})
+	if err == nil {
+		t.Error("NewKeySet() accepted two active keys")
+	}
+
+	_, err = NewKeySet([]KeyConfig{{ID: "a", Secret: "a", Status: KeyVerify}})
+	if err == nil {
+		t.Error("NewKeySet() accepted a key set without an active key")
+	}
+}
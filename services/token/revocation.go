@@ -0,0 +1,41 @@
+package token
+
+import (
+	"time"
+
+	"blog-server/db"
+	"blog-server/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// Revoke 把 access token 加入吊销列表
+func Revoke(jti string, expiresAt time.Time) error {
+	revoked := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	return db.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error
+}
+
+// IsRevoked 检查 access token 本身或其所在会话是否已被吊销
+func IsRevoked(jti, sessionID string) (bool, error) {
+	var count int64
+	if err := db.GetDB().Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if sessionID == "" {
+		return false, nil
+	}
+	err := db.GetDB().Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NOT NULL", sessionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PurgeExpired 删除在 now 之前已经过期的吊销记录，过期的 access token 本身已无法通过校验
+func PurgeExpired(now time.Time) (int64, error) {
+	result := db.GetDB().Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,49 @@
+package tokenizer
+
+import "unicode"
+
+// Bigram 不需要词典的分词：连续的中日韩文字按相邻两字切分（"垃圾回收" -> 垃圾 圾回 回收），
+// 其他连续的字母数字作为一个词
+type Bigram struct{}
+
+func (Bigram) Tokenize(text string) []string {
+	var words []string
+	var cjk, word []rune
+
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			words = append(words, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			words = append(words, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	flushWord := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case isWordRune(r) || r == '\'':
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushCJK()
+			flushWord()
+		}
+	}
+	flushCJK()
+	flushWord()
+	return words
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
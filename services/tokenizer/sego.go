@@ -0,0 +1,35 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/huichen/sego"
+)
+
+// Sego 基于词典的中文分词，搜索模式下长词会额外输出其中的短词
+type Sego struct {
+	seg sego.Segmenter
+}
+
+// NewSego 加载 sego 词典，多个词典用逗号分隔
+// sego 在词典不存在时会直接退出进程，这里先检查文件
+func NewSego(dictionary string) (*Sego, error) {
+	if dictionary == "" {
+		dictionary = "data/dictionary.txt"
+	}
+	for _, file := range strings.Split(dictionary, ",") {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("sego dictionary %q: %w", file, err)
+		}
+	}
+
+	s := &Sego{}
+	s.seg.LoadDictionary(dictionary)
+	return s, nil
+}
+
+func (s *Sego) Tokenize(text string) []string {
+	return sego.SegmentsToSlice(s.seg.Segment([]byte(text)), true)
+}
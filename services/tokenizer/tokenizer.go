@@ -0,0 +1,100 @@
+// Package tokenizer 搜索分词：中文交给 sego 词典分词或二元切分，英文做小写、去停用词和词干提取
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kljensen/snowball/english"
+)
+
+// Tokenizer 把文本切分为可以写入 tsvector / tsquery 的词
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// 分词后端
+const (
+	BackendSego   = "sego"
+	BackendBigram = "bigram"
+)
+
+// Config 分词配置，见 search.tokenizer
+type Config struct {
+	Backend    string // BackendSego 或 BackendBigram，默认 sego
+	Dictionary string // sego 词典路径，多个用逗号分隔
+	Stem       bool   // 英文词干提取
+	StopWords  bool   // 去掉英文停用词
+}
+
+// New 按配置创建分词流水线，sego 词典不存在时返回错误
+func New(cfg Config) (*Pipeline, error) {
+	var backend Tokenizer
+	switch cfg.Backend {
+	case "", BackendSego:
+		seg, err := NewSego(cfg.Dictionary)
+		if err != nil {
+			return nil, err
+		}
+		backend = seg
+	case BackendBigram:
+		backend = Bigram{}
+	default:
+		return nil, fmt.Errorf("unknown tokenizer backend %q", cfg.Backend)
+	}
+	return &Pipeline{Backend: backend, Stem: cfg.Stem, StopWords: cfg.StopWords}, nil
+}
+
+// Pipeline 先用 Backend 切分，再统一转小写，去掉标点和单个字母，英文词按配置去停用词、提取词干
+type Pipeline struct {
+	Backend   Tokenizer
+	Stem      bool
+	StopWords bool
+}
+
+func (p *Pipeline) Tokenize(text string) []string {
+	var result []string
+	for _, word := range p.Backend.Tokenize(text) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len(word) <= 1 || !strings.ContainsFunc(word, isWordRune) {
+			continue
+		}
+		if isLatin(word) {
+			if p.StopWords && english.IsStopWord(word) {
+				continue
+			}
+			word = p.Normalize(word)
+		}
+		result = append(result, word)
+	}
+	return result
+}
+
+// Normalize 对单个英文词做与 Tokenize 相同的处理（小写、词干提取、去撇号），
+// 用于把原文中的词和分词结果比较，如高亮时 running 对应 run
+func (p *Pipeline) Normalize(word string) string {
+	word = strings.ToLower(word)
+	if !isLatin(word) {
+		return word
+	}
+	if p.Stem {
+		word = english.Stem(word, true)
+	}
+	// tsquery 会去掉撇号，索引中也不保留
+	return strings.ReplaceAll(word, "'", "")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isLatin 词中只有拉丁字母、数字和撇号
+func isLatin(word string) bool {
+	for _, r := range word {
+		if !(unicode.Is(unicode.Latin, r) || unicode.IsDigit(r) || r == '\'') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBigram(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "chinese", text: "垃圾回收", want: []string{"垃圾", "圾回", "回收"}},
+		{name: "mixed", text: "Go语言，GC!", want: []string{"Go", "语言", "GC"}},
+		{name: "single han", text: "和 Rust", want: []string{"和", "Rust"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Bigram{}).Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bigram.Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := &Pipeline{Backend: Bigram{}, Stem: true, StopWords: true}
+	got := p.Tokenize("The Running goroutines 调度器 a don't")
+	want := []string{"run", "goroutin", "调度", "度器", "dont"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+
+	plain := &Pipeline{Backend: Bigram{}}
+	got = plain.Tokenize("The Running")
+	want = []string{"the", "running"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize without stemming = %q, want %q", got, want)
+	}
+}
+
+func TestNewSegoMissingDictionary(t *testing.T) {
+	if _, err := New(Config{Backend: BackendSego, Dictionary: "testdata/missing.txt"}); err == nil {
+		t.Error("New with a missing dictionary should fail")
+	}
+}
+
+func TestUserWords(t *testing.T) {
+	u := NewUserWords(Bigram{})
+	u.SetWords([]string{"协程", "gRPC", "go"})
+
+	got := u.Tokenize("Go协程调用GRPC, google")
+	want := []string{"Go", "协程", "调用", "GRPC", "google"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+
+	if got := u.Tokenize("协程池"); !reflect.DeepEqual(got, []string{"协程", "池"}) {
+		t.Errorf("Tokenize = %q, want the user word kept whole", got)
+	}
+
+	u.SetWords(nil)
+	got = u.Tokenize("协程池")
+	want = []string{"协程", "程池"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize after clearing = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package tokenizer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// UserWords 在 Backend 分词前先切出自定义词，自定义词作为一个整体输出，不会被词典切开
+// 词表可以随时通过 SetWords 替换，并发安全
+type UserWords struct {
+	Backend Tokenizer
+
+	mu    sync.RWMutex
+	index map[rune][][]rune // 首字（小写）-> 以它开头的词，按长度降序
+}
+
+func NewUserWords(backend Tokenizer) *UserWords {
+	return &UserWords{Backend: backend}
+}
+
+// SetWords 替换自定义词表，匹配时不区分大小写
+func (u *UserWords) SetWords(words []string) {
+	index := make(map[rune][][]rune)
+	seen := make(map[string]bool)
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		runes := []rune(word)
+		index[runes[0]] = append(index[runes[0]], runes)
+	}
+	for _, list := range index {
+		sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	}
+
+	u.mu.Lock()
+	u.index = index
+	u.mu.Unlock()
+}
+
+func (u *UserWords) Tokenize(text string) []string {
+	u.mu.RLock()
+	index := u.index
+	u.mu.RUnlock()
+	if len(index) == 0 {
+		return u.Backend.Tokenize(text)
+	}
+
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var words []string
+	start := 0
+	for i := 0; i < len(runes); {
+		n := matchUserWord(index, lower, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		if start < i {
+			words = append(words, u.Backend.Tokenize(string(runes[start:i]))...)
+		}
+		words = append(words, string(runes[i:i+n]))
+		i += n
+		start = i
+	}
+	if start < len(runes) {
+		words = append(words, u.Backend.Tokenize(string(runes[start:]))...)
+	}
+	return words
+}
+
+// matchUserWord 返回 text[i:] 开头最长的自定义词长度，没有时返回 0
+// 以字母数字开头或结尾的词要求在单词边界上，避免 go 匹配 google 的开头
+func matchUserWord(index map[rune][][]rune, text []rune, i int) int {
+	for _, word := range index[text[i]] {
+		end := i + len(word)
+		if end > len(text) || !equalRunes(text[i:end], word) {
+			continue
+		}
+		if isLatinWordRune(word[0]) && i > 0 && isLatinWordRune(text[i-1]) {
+			continue
+		}
+		if isLatinWordRune(word[len(word)-1]) && end < len(text) && isLatinWordRune(text[end]) {
+			continue
+		}
+		return len(word)
+	}
+	return 0
+}
+
+func isLatinWordRune(r rune) bool {
+	return isWordRune(r) && !isCJK(r)
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -4,33 +4,155 @@ import (
 	"errors"
 	"time"
 
+	"blog-server/common/request"
 	"blog-server/db"
-	"blog-server/forms"
 	"blog-server/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrUserExists         = errors.New("用户名或手机号已被注册")
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrUserInactive       = errors.New("账号已被禁用")
+	ErrAdminExists        = errors.New("已存在用户，无需初始化管理员")
+)
+
+// 用户不存在时也做一次哈希比较，避免通过响应时间判断用户名是否存在
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
 // 注册用户
-func Signup(userPayload forms.UserSignup) (*models.User, error) {
-	id := uuid.New().String()
+// 登录时用户名和手机号共用一个输入框，所以用户名和手机号都不能与任何已有用户的用户名或手机号相同
+func Signup(form request.Register) (*models.User, error) {
+	identifiers := []string{form.Name}
+	if form.Mobile != "" {
+		identifiers = append(identifiers, form.Mobile)
+	}
+	var count int64
+	if err := db.DB.Model(&models.User{}).
+		Where("name IN ? OR mobile IN ?", identifiers, identifiers).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUserExists
+	}
+
+	hash, err := HashPassword(form.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	user := models.User{
-		ID:       id,
-		Name:     userPayload.Name,
-		BirthDay: userPayload.BirthDay,
-		Gender:   userPayload.Gender,
-		PhotoURL: userPayload.PhotoURL,
-		Time:     time.Now().UnixNano(),
-		Active:   true,
+		ID:           uuid.New().String(),
+		Name:         form.Name,
+		Mobile:       form.Mobile,
+		Email:        form.Email,
+		PasswordHash: hash,
+		Role:         models.RoleReader,
+		Time:         time.Now().UnixNano(),
+		Active:       true,
 	}
 
 	if err := db.DB.Create(&user).Error; err != nil {
+		// 并发注册时可能都通过了上面的检查，由唯一索引兜底
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return nil, ErrUserExists
+		}
 		return nil, errors.New("failed to save user to database")
 	}
 
 	return &user, nil
 }
 
+// Authenticate 使用用户名或手机号 + 密码登录
+func Authenticate(identifier, password string) (*models.User, error) {
+	var user models.User
+	err := db.DB.Where("name = ? OR mobile = ?", identifier, identifier).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.Active {
+		return nil, ErrUserInactive
+	}
+	return &user, nil
+}
+
+// ChangePassword 校验原密码后修改密码，并注销除当前会话 currentSessionID 以外的所有会话
+func ChangePassword(userID, currentSessionID, oldPassword, newPassword string) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+		// 会话注销后其 access token 也随即失效，见 token.IsRevoked
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, currentSessionID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// SeedAdmin 在没有任何用户时创建第一个管理员
+func SeedAdmin(name, mobile, password string) (*models.User, error) {
+	var count int64
+	if err := db.DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAdminExists
+	}
+
+	user, err := Signup(request.Register{Name: name, Mobile: mobile, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	return UpdateUserRole(user.ID, models.RoleAdmin)
+}
+
+// UpdateUserRole 修改用户角色
+func UpdateUserRole(id, role string) (*models.User, error) {
+	user, err := GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.DB.Model(user).Update("role", role).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // 根据 ID 查询用户
 func GetUserByID(id string) (*models.User, error) {
 	var user models.User
@@ -5,6 +5,8 @@ import (
 
 	"blog-server/config"
 	"blog-server/controllers"
+	"blog-server/middlewares"
+	"blog-server/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -34,7 +36,7 @@ func SetupRouter() *gin.Engine {
 		userGroup := v1.Group("user")
 		{
 			user := new(controllers.UserController)
-			userGroup.GET("/:id", user.Retrieve)
+			userGroup.GET("/:id", middlewares.JWTMiddleware(), utils.BindAndRespond(user.Retrieve))
 		}
 	}
 	return router
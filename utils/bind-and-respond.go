@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"blog-server/common/request"
 	"errors"
 	"net/http"
 
@@ -89,9 +90,14 @@ func BindAndRespondR[TReq any, TRes any](handler func(c *gin.Context, req TReq)
 		}
 
 		if err != nil {
+			message := err.Error()
+			// 实现了 request.Validator 的请求结构体使用自定义错误信息
+			if _, ok := any(req).(request.Validator); ok {
+				message = request.GetErrorMsg(req, err)
+			}
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":  http.StatusBadRequest,
-				"error": err.Error(),
+				"error": message,
 			})
 			return
 		}
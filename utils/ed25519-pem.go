@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// ParseEd25519PrivateKeyFromPEM 解析 PKCS#8 格式的 Ed25519 私钥
+func ParseEd25519PrivateKeyFromPEM(pemBytes []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("PEM 解码失败")
+	}
+	// 和风的示例是 PKCS#8
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#8 解析失败: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("不是 Ed25519 私钥")
+	}
+	return priv, nil
+}
+
+// ParseEd25519PublicKeyFromPEM 解析 PKIX 格式的 Ed25519 公钥
+func ParseEd25519PublicKeyFromPEM(pemBytes []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("PEM 解码失败")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("PKIX 解析失败: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("不是 Ed25519 公钥")
+	}
+	return pub, nil
+}
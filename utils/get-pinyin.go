@@ -2,6 +2,7 @@ package utils
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/mozillazg/go-pinyin"
 )
@@ -21,3 +22,28 @@ func GetPinYin(hans string) string {
 
 	return strings.Join(result, "")
 }
+
+// PinyinKeys 返回用于拼音检索的全拼和首字母，汉字转为拼音，英文字母和数字转为小写原样保留，其他字符忽略
+// 连续的字母数字算一个词，首字母中只取第一个字符，例如 "Go 北京" 返回 "gobeijing" 和 "gbj"
+func PinyinKeys(text string) (full, initials string) {
+	var fb, ib strings.Builder
+	inWord := false
+	for _, r := range text {
+		isWord := r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if py := GetPinYin(string(r)); py != "" {
+				fb.WriteString(py)
+				ib.WriteByte(py[0])
+			}
+		case isWord:
+			r = unicode.ToLower(r)
+			fb.WriteRune(r)
+			if !inWord {
+				ib.WriteRune(r)
+			}
+		}
+		inWord = isWord
+	}
+	return fb.String(), ib.String()
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestPinyinKeys(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantFull     string
+		wantInitials string
+	}{
+		{name: "chinese", text: "北京", wantFull: "beijing", wantInitials: "bj"},
+		{name: "mixed", text: "Go 并发", wantFull: "gobingfa", wantInitials: "gbf"},
+		{name: "words", text: "Kubernetes入门k8s", wantFull: "kubernetesrumenk8s", wantInitials: "krmk"},
+		{name: "symbols", text: "C++!", wantFull: "c", wantInitials: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full, initials := PinyinKeys(tt.text)
+			if full != tt.wantFull || initials != tt.wantInitials {
+				t.Errorf("PinyinKeys(%q) = %q, %q, want %q, %q", tt.text, full, initials, tt.wantFull, tt.wantInitials)
+			}
+		})
+	}
+}
@@ -2,14 +2,14 @@ package hefeng
 
 import (
 	"crypto/ed25519"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
 	"time"
+
+	"blog-server/utils"
 )
 
 // 生成 EdDSA (Ed25519) JWT。ttl 建议前端短、服务端长，但<=24h。
@@ -23,7 +23,7 @@ func GenerateJWT(kid, sub string, privPEM []byte, ttl time.Duration) (string, er
 	}
 
 	// 解析 PKCS#8 Ed25519 私钥
-	priv, err := parseEd25519PrivateKeyFromPEM(privPEM)
+	priv, err := utils.ParseEd25519PrivateKeyFromPEM(privPEM)
 	if err != nil {
 		return "", fmt.Errorf("解析私钥失败: %w", err)
 	}
@@ -63,23 +63,6 @@ func GenerateJWT(kid, sub string, privPEM []byte, ttl time.Duration) (string, er
 	return signingInput + "." + sEnc, nil
 }
 
-func parseEd25519PrivateKeyFromPEM(pemBytes []byte) (ed25519.PrivateKey, error) {
-	block, _ := pem.Decode(pemBytes)
-	if block == nil {
-		return nil, errors.New("PEM 解码失败")
-	}
-	// 和风的示例是 PKCS#8
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("PKCS#8 解析失败: %w", err)
-	}
-	priv, ok := key.(ed25519.PrivateKey)
-	if !ok {
-		return nil, errors.New("不是 Ed25519 私钥")
-	}
-	return priv, nil
-}
-
 func GenerateToken() (string, error) {
 	const kid = "TN5849VDFJ"
 	const sub = "2FKRUP4CBG"
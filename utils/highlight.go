@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"html"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// HighlightOptions 高亮参数，含义与 PostgreSQL ts_headline 的同名选项一致
+type HighlightOptions struct {
+	StartSel          string // 命中词前插入的标记，如 <mark>
+	StopSel           string // 命中词后插入的标记，如 </mark>
+	MaxLength         int    // 摘要最多包含的字符数（不含标记），<= 0 表示不截断
+	MaxFragments      int    // 摘要最多包含的片段数，<= 0 时为 1
+	FragmentDelimiter string // 片段之间的分隔符
+	EscapeHTML        bool   // 转义原文中的 HTML，标记本身不转义
+	// Normalize 比较由字母数字组成的词之前对原文中的词做的处理（已转小写），如词干提取，使 running 能命中 run；
+	// 搜索词经过词干提取时必须设置，nil 表示只比较小写形式
+	Normalize func(word string) string
+}
+
+// DefaultHighlightOptions 默认用 <mark> 标记命中词，输出可以直接作为 HTML 渲染
+var DefaultHighlightOptions = HighlightOptions{
+	StartSel:          "<mark>",
+	StopSel:           "</mark>",
+	MaxLength:         200,
+	MaxFragments:      1,
+	FragmentDelimiter: " ... ",
+	EscapeHTML:        true,
+}
+
+type matchSpan struct {
+	start, end int // rune 下标，左闭右开
+	term       int // 命中的是第几个词
+}
+
+// Highlight 在 text 中标记所有命中 terms 的位置，不截断
+func Highlight(text string, terms []string, opts HighlightOptions) string {
+	runes := []rune(text)
+	return renderHighlight(runes, 0, len(runes), findMatches(runes, terms, opts.Normalize), opts)
+}
+
+// HighlightSnippet 从 text 中选出命中词最多的片段并标记命中词
+// 连续空白会被压缩为一个空格；没有命中时返回开头 MaxLength 个字符
+func HighlightSnippet(text string, terms []string, opts HighlightOptions) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	matches := findMatches(runes, terms, opts.Normalize)
+
+	if opts.MaxLength <= 0 || len(runes) <= opts.MaxLength {
+		return renderHighlight(runes, 0, len(runes), matches, opts)
+	}
+	if len(matches) == 0 {
+		return renderHighlight(runes, 0, opts.MaxLength, nil, opts) + "..."
+	}
+
+	fragments := opts.MaxFragments
+	if fragments <= 0 {
+		fragments = 1
+	}
+	// 命中词比窗口还长时放宽窗口，否则任何窗口都装不下完整的命中词
+	size := opts.MaxLength / fragments
+	for _, m := range matches {
+		size = max(size, m.end-m.start)
+	}
+	windows := pickWindows(len(runes), matches, size, fragments)
+	if len(windows) == 0 {
+		return renderHighlight(runes, 0, opts.MaxLength, nil, opts) + "..."
+	}
+
+	var b strings.Builder
+	if windows[0][0] > 0 {
+		b.WriteString("...")
+	}
+	for i, w := range windows {
+		if i > 0 {
+			b.WriteString(opts.FragmentDelimiter)
+		}
+		b.WriteString(renderHighlight(runes, w[0], w[1], matches, opts))
+	}
+	if windows[len(windows)-1][1] < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// findMatches 不区分大小写地查找所有命中位置，重叠时保留靠前、较长的一个
+// 由字母数字组成的词只匹配完整单词，避免 go 命中 google，比较前经过 normalize；中文等不做边界限制
+func findMatches(runes []rune, terms []string, normalize func(string) string) []matchSpan {
+	if normalize == nil {
+		normalize = func(word string) string { return word }
+	}
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var matches []matchSpan
+	words := make(map[string]int) // 规范化后的单词 -> 词的下标
+	for ti, term := range terms {
+		t := []rune(strings.TrimSpace(term))
+		if len(t) == 0 {
+			continue
+		}
+		for i, r := range t {
+			t[i] = unicode.ToLower(r)
+		}
+		if !slices.ContainsFunc(t, func(r rune) bool { return !isWordRune(r) }) {
+			// 词干提取不一定幂等，搜索词可能已经是词干，原样和规范化后的形式都参与比较
+			for _, key := range []string{string(t), normalize(string(t))} {
+				if _, ok := words[key]; !ok && key != "" {
+					words[key] = ti
+				}
+			}
+			continue
+		}
+		wordLike := isWordRune(t[0]) && isWordRune(t[len(t)-1])
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if !runesEqual(lower[i:i+len(t)], t) {
+				continue
+			}
+			end := i + len(t)
+			if wordLike && ((i > 0 && isWordRune(lower[i-1])) || (end < len(lower) && isWordRune(lower[end]))) {
+				continue
+			}
+			matches = append(matches, matchSpan{start: i, end: end, term: ti})
+		}
+	}
+
+	// 逐个比较原文中由字母数字组成的完整单词，如 node.js 分别比较 node 和 js
+	if len(words) > 0 {
+		for i := 0; i < len(lower); {
+			if !isWordRune(lower[i]) {
+				i++
+				continue
+			}
+			end := i
+			for end < len(lower) && isWordRune(lower[end]) {
+				end++
+			}
+			if ti, ok := words[normalize(string(lower[i:end]))]; ok {
+				matches = append(matches, matchSpan{start: i, end: end, term: ti})
+			}
+			i = end
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+	result := matches[:0]
+	last := -1
+	for _, m := range matches {
+		if m.start >= last {
+			result = append(result, m)
+			last = m.end
+		}
+	}
+	return result
+}
+
+// pickWindows 依次选出包含不同命中词最多的窗口，窗口互不重叠，按出现顺序返回
+// matches 按位置排序且互不重叠，候选窗口随命中位置单调右移，用双指针维护窗口内的命中，每个片段只需扫描一遍
+func pickWindows(total int, matches []matchSpan, size, count int) [][2]int {
+	if size <= 0 {
+		size = 1
+	}
+	lead := size / 4 // 命中词前保留的上下文
+
+	var windows [][2]int
+	for len(windows) < count {
+		best, bestScore := [2]int{}, 0
+		terms := make(map[int]int) // 窗口内每个词的命中次数
+		lo, hi := 0, 0             // 窗口内的命中为 matches[lo:hi]
+		for _, m := range matches {
+			// 命中词较长时窗口右移，保证能装下整个命中词
+			start := max(m.start-lead, m.end-size, 0)
+			end := min(start+size, total)
+			start = max(end-size, 0)
+
+			for lo < len(matches) && matches[lo].start < start {
+				if lo < hi {
+					if terms[matches[lo].term]--; terms[matches[lo].term] == 0 {
+						delete(terms, matches[lo].term)
+					}
+				}
+				lo++
+			}
+			hi = max(hi, lo)
+			for hi < len(matches) && matches[hi].end <= end {
+				terms[matches[hi].term]++
+				hi++
+			}
+
+			if overlapsAny(windows, start, end) {
+				continue
+			}
+			if score := len(terms)*1000 + hi - lo; score > bestScore {
+				best, bestScore = [2]int{start, end}, score
+			}
+		}
+		if bestScore == 0 {
+			break
+		}
+		windows = append(windows, best)
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i][0] < windows[j][0] })
+	return windows
+}
+
+func overlapsAny(windows [][2]int, start, end int) bool {
+	for _, w := range windows {
+		if start < w[1] && w[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// renderHighlight 输出 runes[start:end]，完整落在区间内的命中词加上标记
+func renderHighlight(runes []rune, start, end int, matches []matchSpan, opts HighlightOptions) string {
+	escape := func(s string) string {
+		if opts.EscapeHTML {
+			return html.EscapeString(s)
+		}
+		return s
+	}
+
+	var b strings.Builder
+	pos := start
+	for _, m := range matches {
+		if m.start < pos || m.end > end {
+			continue
+		}
+		b.WriteString(escape(string(runes[pos:m.start])))
+		b.WriteString(opts.StartSel)
+		b.WriteString(escape(string(runes[m.start:m.end])))
+		b.WriteString(opts.StopSel)
+		pos = m.end
+	}
+	b.WriteString(escape(string(runes[pos:end])))
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
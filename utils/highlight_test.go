@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHighlight(t *testing.T) {
+	opts := HighlightOptions{StartSel: "[", StopSel: "]"}
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "chinese", text: "Go 语言的并发模型", terms: []string{"并发"}, want: "Go 语言的[并发]模型"},
+		{name: "case insensitive", text: "Golang and GO", terms: []string{"go"}, want: "Golang and [GO]"},
+		{name: "longest wins", text: "并发编程", terms: []string{"并发", "并发编程"}, want: "[并发编程]"},
+		{name: "no match", text: "hello", terms: []string{"world"}, want: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.terms, opts); got != tt.want {
+				t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.terms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	opts := HighlightOptions{StartSel: "<b>", StopSel: "</b>", MaxLength: 10, MaxFragments: 1, EscapeHTML: true}
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "short text", text: "a <b>\n\nc", terms: []string{"c"}, want: "a &lt;b&gt; <b>c</b>"},
+		{name: "window around match", text: "一二三四五六七八九十并发一二三四五六七八九十", terms: []string{"并发"}, want: "...九十<b>并发</b>一二三四五六..."},
+		{name: "window at end", text: "一二三四五六七八九十一二三四五六七八九十并发", terms: []string{"并发"}, want: "...三四五六七八九十<b>并发</b>"},
+		{name: "no match", text: "一二三四五六七八九十一二三", terms: []string{"并发"}, want: "一二三四五六七八九十..."},
+		{name: "match longer than window", text: "ab internationalization cd", terms: []string{"internationalization"}, want: "...<b>internationalization</b>..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HighlightSnippet(tt.text, tt.terms, opts); got != tt.want {
+				t.Errorf("HighlightSnippet(%q, %q) = %q, want %q", tt.text, tt.terms, got, tt.want)
+			}
+		})
+	}
+}
+
+// 命中词超过 MaxLength/MaxFragments 时不能 panic
+func TestHighlightSnippetOversizedMatch(t *testing.T) {
+	term := strings.Repeat("a", 250)
+	text := "intro " + term + " outro"
+	got := HighlightSnippet(text, []string{term}, DefaultHighlightOptions)
+	if want := "...<mark>" + term + "</mark>..."; got != want {
+		t.Errorf("HighlightSnippet() = %q, want %q", got, want)
+	}
+
+	opts := DefaultHighlightOptions
+	opts.MaxFragments = 10
+	if got := HighlightSnippet(strings.Repeat("x ", 100)+"internationalizations", []string{"internationalizations"}, opts); !strings.Contains(got, "<mark>internationalizations</mark>") {
+		t.Errorf("HighlightSnippet() = %q, want the whole word highlighted", got)
+	}
+}
+
+// 常见字在长文中命中上万次时，选片段的耗时应与命中数成线性关系
+func TestHighlightSnippetManyMatches(t *testing.T) {
+	text := strings.Repeat("的一", 100000)
+	opts := HighlightOptions{StartSel: "[", StopSel: "]", MaxLength: 10, MaxFragments: 2, FragmentDelimiter: " | "}
+
+	done := make(chan string, 1)
+	go func() { done <- HighlightSnippet(text, []string{"的"}, opts) }()
+	select {
+	case got := <-done:
+		if want := "[的]一[的]一[的] | 一[的]一[的]一..."; got != want {
+			t.Errorf("HighlightSnippet() = %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HighlightSnippet() took too long")
+	}
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// 行级 diff 的操作类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// MaxDiffLines 两段文本合计超过该行数时不计算差异
+const MaxDiffLines = 10000
+
+var ErrDiffTooLarge = errors.New("文本过长，无法对比")
+
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"` // 在旧文本中的行号（从 1 开始），insert 时为 0
+	NewLine int    `json:"newLine,omitempty"` // 在新文本中的行号（从 1 开始），delete 时为 0
+}
+
+// DiffLines 用 Myers 算法计算两段文本的行级差异，只使用线性内存
+// 两段文本合计超过 MaxDiffLines 行时返回 ErrDiffTooLarge
+func DiffLines(oldText, newText string) ([]DiffLine, error) {
+	d := &lineDiffer{a: splitLines(oldText), b: splitLines(newText)}
+	if len(d.a)+len(d.b) > MaxDiffLines {
+		return nil, ErrDiffTooLarge
+	}
+	d.diff(0, len(d.a), 0, len(d.b))
+	return d.result, nil
+}
+
+type lineDiffer struct {
+	a, b   []string
+	result []DiffLine
+}
+
+func (d *lineDiffer) equal(i, j int) {
+	d.result = append(d.result, DiffLine{Op: DiffEqual, Text: d.a[i], OldLine: i + 1, NewLine: j + 1})
+}
+
+// diff 计算 a[a0:a1] 与 b[b0:b1] 的差异并按顺序追加到 result
+func (d *lineDiffer) diff(a0, a1, b0, b1 int) {
+	// 去掉相同的开头和结尾
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.equal(a0, b0)
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+	a1 -= suffix
+	b1 -= suffix
+
+	if x, y, ok := d.bisect(a0, a1, b0, b1); ok {
+		d.diff(a0, x, b0, y)
+		d.diff(x, a1, y, b1)
+	} else {
+		for i := a0; i < a1; i++ {
+			d.result = append(d.result, DiffLine{Op: DiffDelete, Text: d.a[i], OldLine: i + 1})
+		}
+		for j := b0; j < b1; j++ {
+			d.result = append(d.result, DiffLine{Op: DiffInsert, Text: d.b[j], NewLine: j + 1})
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.equal(a1+i, b1+i)
+	}
+}
+
+// bisect 从两端同时搜索最短编辑路径，返回两条路径相遇的位置
+// 没有相同的行（或其中一段为空）时返回 false，此时整段都是删除和插入
+func (d *lineDiffer) bisect(a0, a1, b0, b1 int) (int, int, bool) {
+	n, m := a1-a0, b1-b0
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	size := 2*maxD + 3
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// 路径超出边界的对角线不再继续搜索
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if bk := offset + delta - k; bk >= 0 && bk < size && backward[bk] != -1 && x >= n-backward[bk] {
+					return a0 + x, b0 + y, true
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a1-x-1] == d.b[b1-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if fk := offset + delta - k; fk >= 0 && fk < size && forward[fk] != -1 {
+					fx := forward[fk]
+					fy := fx - (fk - offset)
+					if fx >= n-x {
+						return a0 + fx, b0 + fy, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []DiffLine
+	}{
+		{
+			name: "identical text",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: []DiffLine{
+				{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+				{Op: DiffEqual, Text: "b", OldLine: 2, NewLine: 2},
+			},
+		},
+		{
+			name: "changed middle line",
+			old:  "a\nb\nc",
+			new:  "a\nx\nc",
+			want: []DiffLine{
+				{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+				{Op: DiffDelete, Text: "b", OldLine: 2},
+				{Op: DiffInsert, Text: "x", NewLine: 2},
+				{Op: DiffEqual, Text: "c", OldLine: 3, NewLine: 3},
+			},
+		},
+		{
+			name: "empty old text",
+			old:  "",
+			new:  "a\r\nb",
+			want: []DiffLine{
+				{Op: DiffInsert, Text: "a", NewLine: 1},
+				{Op: DiffInsert, Text: "b", NewLine: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffLines(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("DiffLines() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 差异应当是最短的，且按顺序还原出新旧文本
+func TestDiffLinesMinimal(t *testing.T) {
+	tests := []struct {
+		old, new string
+		edits    int
+	}{
+		{old: "a\nb\nc\na\nb\nb\na", new: "c\nb\na\nb\na\nc", edits: 5},
+		{old: "a\nb\nc", new: "x\ny\nz", edits: 6},
+		{old: "a\nb\nc\nd", new: "b\nd", edits: 2},
+		{old: "x\na\nb", new: "a\nb\nx\ny", edits: 3},
+	}
+	for _, tt := range tests {
+		got, err := DiffLines(tt.old, tt.new)
+		if err != nil {
+			t.Fatalf("DiffLines(%q, %q) error = %v", tt.old, tt.new, err)
+		}
+		var oldLines, newLines []string
+		edits := 0
+		for _, line := range got {
+			if line.Op != DiffInsert {
+				oldLines = append(oldLines, line.Text)
+			}
+			if line.Op != DiffDelete {
+				newLines = append(newLines, line.Text)
+			}
+			if line.Op != DiffEqual {
+				edits++
+			}
+		}
+		if !reflect.DeepEqual(oldLines, splitLines(tt.old)) || !reflect.DeepEqual(newLines, splitLines(tt.new)) {
+			t.Errorf("DiffLines(%q, %q) = %v does not reproduce the input", tt.old, tt.new, got)
+		}
+		if edits != tt.edits {
+			t.Errorf("DiffLines(%q, %q) has %d edits, want %d", tt.old, tt.new, edits, tt.edits)
+		}
+	}
+
+	if _, err := DiffLines(strings.Repeat("a\n", MaxDiffLines), "b"); !errors.Is(err, ErrDiffTooLarge) {
+		t.Errorf("DiffLines() on large input error = %v, want ErrDiffTooLarge", err)
+	}
+}
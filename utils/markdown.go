@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// HeadingIDs 生成标题锚点：与文章 slug 规则一致（汉字转拼音），重复时追加序号
+type HeadingIDs struct {
+	used map[string]bool
+}
+
+func NewHeadingIDs() *HeadingIDs {
+	return &HeadingIDs{used: make(map[string]bool)}
+}
+
+func (h *HeadingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := Slugify(string(value))
+	if base == "" {
+		base = "heading"
+	}
+	id := base
+	for i := 1; h.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	h.used[id] = true
+	return []byte(id)
+}
+
+func (h *HeadingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM, // 表格、任务列表、删除线、自动链接
+		extension.Footnote,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle("github"),
+			// 输出 class 而不是内联样式，由前端提供配色
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+var (
+	safeClass = regexp.MustCompile(`^[\w\- ]+$`)
+	safeID    = regexp.MustCompile(`^[\w\-:]+$`)
+)
+
+// htmlPolicy 在 UGC 策略基础上放行高亮 class、标题锚点、脚注和任务列表复选框
+var htmlPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(safeClass).OnElements("pre", "code", "span", "div", "a", "li", "sup", "input", "ul")
+	p.AllowAttrs("id").Matching(safeID).OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-[a-z]+$`)).OnElements("a", "div", "section")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// RenderMarkdown 把 Markdown 渲染为经过过滤的 HTML，原始 HTML 标签不会输出
+func RenderMarkdown(source []byte) (string, error) {
+	var buf bytes.Buffer
+	ctx := parser.NewContext(parser.WithIDs(NewHeadingIDs()))
+	if err := markdown.Convert(source, &buf, parser.WithContext(ctx)); err != nil {
+		return "", err
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
+
+// Heading 目录中的一个标题，ID 与渲染后 HTML 中的锚点一致
+type Heading struct {
+	Level    int       `json:"level"`
+	Text     string    `json:"text"`
+	ID       string    `json:"id"`
+	Children []Heading `json:"children,omitempty"`
+}
+
+// MarkdownStats 文章目录和字数统计
+type MarkdownStats struct {
+	TOC []Heading
+	// 汉字、假名、谚文每个字算一个字，其余按单词计
+	WordCount int
+	// 预计阅读分钟数
+	ReadingTime int
+}
+
+// 阅读速度：中日韩文字每分钟 300 字，其他语言每分钟 200 词
+const (
+	cjkCharsPerMinute = 300
+	wordsPerMinute    = 200
+)
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// countWords 分别统计中日韩文字数和其他语言的单词数
+func countWords(text string) (cjk, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		case r == '\'' || r == '’':
+			// don't、it's 算一个词
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+// nodeText 拼接节点下的纯文本
+func nodeText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// AnalyzeMarkdown 生成标题目录并统计正文字数，代码块和原始 HTML 不计入字数
+func AnalyzeMarkdown(source []byte) MarkdownStats {
+	ctx := parser.NewContext(parser.WithIDs(NewHeadingIDs()))
+	doc := markdown.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	var stats MarkdownStats
+	var cjk, words int
+	// stack 保存当前路径上每一级标题在树中的位置
+	var stack []*Heading
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML, *ast.AutoLink:
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Heading:
+			h := Heading{Level: node.Level, Text: nodeText(node, source)}
+			if id, ok := node.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					h.ID = string(b)
+				}
+			}
+
+			for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+				stack = stack[:len(stack)-1]
+			}
+			var siblings *[]Heading
+			if len(stack) == 0 {
+				siblings = &stats.TOC
+			} else {
+				siblings = &stack[len(stack)-1].Children
+			}
+			*siblings = append(*siblings, h)
+			stack = append(stack, &(*siblings)[len(*siblings)-1])
+
+		case *ast.Text:
+			c, w := countWords(string(node.Segment.Value(source)))
+			cjk += c
+			words += w
+		case *ast.String:
+			c, w := countWords(string(node.Value))
+			cjk += c
+			words += w
+		}
+		return ast.WalkContinue, nil
+	})
+
+	stats.WordCount = cjk + words
+	if stats.WordCount > 0 {
+		minutes := float64(cjk)/cjkCharsPerMinute + float64(words)/wordsPerMinute
+		stats.ReadingTime = max(int(math.Ceil(minutes)), 1)
+	}
+	return stats
+}
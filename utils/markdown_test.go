@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "heading anchors",
+			markdown: "# Go 并发编程\n\n## Go 并发编程\n",
+			contains: []string{`<h1 id="go-bing-fa-bian-cheng">`, `<h2 id="go-bing-fa-bian-cheng-1">`},
+		},
+		{
+			name:     "table",
+			markdown: "| a | b |\n|---|---|\n| 1 | 2 |\n",
+			contains: []string{"<table>", "<td>1</td>"},
+		},
+		{
+			name:     "task list",
+			markdown: "- [x] done\n- [ ] todo\n",
+			contains: []string{`<input checked="" disabled="" type="checkbox">`, `<input disabled="" type="checkbox">`},
+		},
+		{
+			name:     "footnote",
+			markdown: "Text[^1]\n\n[^1]: note\n",
+			contains: []string{`<sup id="fnref:1">`, `<li id="fn:1">`},
+		},
+		{
+			name:     "fenced code highlighting",
+			markdown: "```go\nfunc main() {}\n```\n",
+			contains: []string{`<pre class="chroma">`, `<span class="kd">func</span>`},
+			excludes: []string{"style="},
+		},
+		{
+			name:     "raw html and script",
+			markdown: "<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n",
+			excludes: []string{"<script", "onerror"},
+		},
+		{
+			name:     "javascript link",
+			markdown: "[x](javascript:alert(1))\n",
+			excludes: []string{"javascript:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderMarkdown([]byte(tt.markdown))
+			if err != nil {
+				t.Fatalf("RenderMarkdown() error = %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("RenderMarkdown() = %q, want it to contain %q", got, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("RenderMarkdown() = %q, want it not to contain %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeMarkdown(t *testing.T) {
+	source := "# 简介\n\nHello world, it's Go.\n\n## Install\n\n```go\nfunc main() {}\n```\n\n### 二级 `code`\n\n## Usage\n\n# 总结\n"
+	got := AnalyzeMarkdown([]byte(source))
+
+	want := []Heading{
+		{Level: 1, Text: "简介", ID: "jian-jie", Children: []Heading{
+			{Level: 2, Text: "Install", ID: "install", Children: []Heading{
+				{Level: 3, Text: "二级 code", ID: "er-ji-code"},
+			}},
+			{Level: 2, Text: "Usage", ID: "usage"},
+		}},
+		{Level: 1, Text: "总结", ID: "zong-jie"},
+	}
+	if !reflect.DeepEqual(got.TOC, want) {
+		t.Errorf("AnalyzeMarkdown().TOC = %+v, want %+v", got.TOC, want)
+	}
+
+	// 简介 二级 总结 共 6 个汉字；Hello world it's Go Install code Usage 共 7 个单词；代码块不计入
+	if got.WordCount != 13 {
+		t.Errorf("AnalyzeMarkdown().WordCount = %d, want 13", got.WordCount)
+	}
+	if got.ReadingTime != 1 {
+		t.Errorf("AnalyzeMarkdown().ReadingTime = %d, want 1", got.ReadingTime)
+	}
+
+	long := AnalyzeMarkdown([]byte(strings.Repeat("字", 900)))
+	if long.ReadingTime != 3 {
+		t.Errorf("ReadingTime for 900 CJK characters = %d, want 3", long.ReadingTime)
+	}
+	if empty := AnalyzeMarkdown(nil); empty.WordCount != 0 || empty.ReadingTime != 0 || empty.TOC != nil {
+		t.Errorf("AnalyzeMarkdown(nil) = %+v, want zero value", empty)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// slug 最大长度，超出时在连字符处截断
+const maxSlugLen = 80
+
+// Slugify 把标题转换成 URL 友好的 slug
+// 汉字逐字转为拼音，英文字母转小写，其余字符作为分隔符，例如 "Go 并发编程" -> "go-bing-fa-bian-cheng"
+func Slugify(text string) string {
+	var parts []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			parts = append(parts, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := GetPinYin(string(r)); py != "" {
+				parts = append(parts, py)
+			}
+		case r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := strings.Join(parts, "-")
+	if len(slug) > maxSlugLen {
+		slug = slug[:maxSlugLen]
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
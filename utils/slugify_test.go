@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "english title", title: "Hello, World!", want: "hello-world"},
+		{name: "chinese title", title: "北京", want: "bei-jing"},
+		{name: "mixed title", title: "Go 并发编程", want: "go-bing-fa-bian-cheng"},
+		{name: "digits and symbols", title: "  Go 1.22 -- 新特性 ", want: "go-1-22-xin-te-xing"},
+		{name: "no usable characters", title: "!!!", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.title); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// NormalizeTagName 去掉首尾空白，并把连续空白合并为一个空格，例如 " Go  语言 " -> "Go 语言"
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// TagNameKey 判断标签是否重复使用的键，忽略大小写和多余空白，"Go"、"go "、"GO" 得到同一个键
+func TagNameKey(name string) string {
+	return strings.ToLower(NormalizeTagName(name))
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestTagNameKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		wantName string
+		wantKey  string
+	}{
+		{name: "mixed case", tag: "GO", wantName: "GO", wantKey: "go"},
+		{name: "trailing space", tag: "go ", wantName: "go", wantKey: "go"},
+		{name: "inner whitespace", tag: "  Machine \t Learning ", wantName: "Machine Learning", wantKey: "machine learning"},
+		{name: "chinese", tag: " 并发 编程", wantName: "并发 编程", wantKey: "并发 编程"},
+		{name: "blank", tag: "   ", wantName: "", wantKey: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTagName(tt.tag); got != tt.wantName {
+				t.Errorf("NormalizeTagName(%q) = %q, want %q", tt.tag, got, tt.wantName)
+			}
+			if got := TagNameKey(tt.tag); got != tt.wantKey {
+				t.Errorf("TagNameKey(%q) = %q, want %q", tt.tag, got, tt.wantKey)
+			}
+		})
+	}
+}